@@ -0,0 +1,143 @@
+package aferofs
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/ocean2811/goseaweedfs"
+)
+
+// file is either a readable file, backed by http.File of filer, or a writable one, streaming to filer.
+type file struct {
+	fs   *Fs
+	name string
+
+	// read side
+	http.File
+
+	// write side
+	w      *goseaweedfs.UploadWriter
+	closed bool
+}
+
+func newWriter(fs *Fs, name string, opts []goseaweedfs.UploadOption) *file {
+	return &file{
+		fs:   fs,
+		name: name,
+		w:    fs.filer.NewWriter(clean(name), "", "", opts...),
+	}
+}
+
+func (f *file) writable() bool {
+	return f.w != nil
+}
+
+func (f *file) Name() string {
+	return f.name
+}
+
+// Close commits written content for writable file.
+func (f *file) Close() error {
+	if !f.writable() {
+		return f.File.Close()
+	}
+
+	if f.closed {
+		return &os.PathError{Op: "close", Path: f.name, Err: os.ErrClosed}
+	}
+
+	f.closed = true
+	return pathError("close", f.name, f.w.Close())
+}
+
+func (f *file) Read(p []byte) (int, error) {
+	if f.writable() {
+		return 0, &os.PathError{Op: "read", Path: f.name, Err: ErrNotSupported}
+	}
+	return f.File.Read(p)
+}
+
+// ReadAt reads with a range request, without changing current offset.
+func (f *file) ReadAt(p []byte, off int64) (n int, err error) {
+	if f.writable() {
+		return 0, &os.PathError{Op: "read", Path: f.name, Err: ErrNotSupported}
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	header := http.Header{"Range": []string{"bytes=" + strconv.FormatInt(off, 10) + "-" + strconv.FormatInt(off+int64(len(p))-1, 10)}}
+	result, err := f.fs.filer.Fetch(clean(f.name), nil, header)
+	if err != nil {
+		return 0, pathError("read", f.name, err)
+	}
+	defer result.Close()
+
+	n, err = io.ReadFull(result.Body, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	if f.writable() {
+		return 0, &os.PathError{Op: "seek", Path: f.name, Err: ErrNotSupported}
+	}
+	return f.File.Seek(offset, whence)
+}
+
+func (f *file) Write(p []byte) (int, error) {
+	if !f.writable() {
+		return 0, &os.PathError{Op: "write", Path: f.name, Err: os.ErrPermission}
+	}
+	return f.w.Write(p)
+}
+
+// ReadFrom streams r directly to filer.
+func (f *file) ReadFrom(r io.Reader) (int64, error) {
+	if !f.writable() {
+		return 0, &os.PathError{Op: "write", Path: f.name, Err: os.ErrPermission}
+	}
+	return f.w.ReadFrom(r)
+}
+
+func (f *file) WriteAt(p []byte, off int64) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: ErrNotSupported}
+}
+
+func (f *file) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+func (f *file) Readdir(count int) ([]os.FileInfo, error) {
+	if f.writable() {
+		return nil, &os.PathError{Op: "readdir", Path: f.name, Err: ErrNotSupported}
+	}
+	return f.File.Readdir(count)
+}
+
+func (f *file) Readdirnames(n int) (names []string, err error) {
+	entries, err := f.Readdir(n)
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return
+}
+
+func (f *file) Stat() (os.FileInfo, error) {
+	if f.writable() {
+		return f.fs.Stat(f.name)
+	}
+	return f.File.Stat()
+}
+
+func (f *file) Sync() error {
+	return nil
+}
+
+func (f *file) Truncate(size int64) error {
+	return &os.PathError{Op: "truncate", Path: f.name, Err: ErrNotSupported}
+}
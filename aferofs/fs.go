@@ -0,0 +1,159 @@
+// Package aferofs implements afero.Fs backed by SeaweedFS filer, so afero based tools could use SeaweedFS as storage backend.
+package aferofs
+
+import (
+	"errors"
+	"os"
+	"path"
+	"time"
+
+	"github.com/ocean2811/goseaweedfs"
+	"github.com/spf13/afero"
+)
+
+// ErrNotSupported return for operations which filer could not do, e.g. chmod, partial write.
+var ErrNotSupported = errors.New("Operation not supported")
+
+// Fs afero.Fs backed by a filer.
+//
+// Files opened for writing are streamed to filer and committed on Close. Filer does not support
+// partial updates, so writing always replaces whole file content and WriteAt/Seek are not supported
+// on writable files.
+type Fs struct {
+	filer *goseaweedfs.Filer
+	hfs   *goseaweedfs.FileSystem
+}
+
+var _ afero.Fs = (*Fs)(nil)
+
+// New creates afero.Fs backed by filer.
+func New(filer *goseaweedfs.Filer) *Fs {
+	return &Fs{
+		filer: filer,
+		hfs:   filer.FileSystem("/"),
+	}
+}
+
+// Name of this file system.
+func (fs *Fs) Name() string {
+	return "SeaweedFS"
+}
+
+// Create creates or truncates named file.
+func (fs *Fs) Create(name string) (afero.File, error) {
+	return fs.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// Mkdir creates a directory.
+func (fs *Fs) Mkdir(name string, perm os.FileMode) error {
+	if _, err := fs.Stat(name); err == nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: os.ErrExist}
+	}
+	return pathError("mkdir", name, fs.filer.Mkdir(clean(name)))
+}
+
+// MkdirAll creates a directory and all missing parents.
+func (fs *Fs) MkdirAll(name string, perm os.FileMode) error {
+	return pathError("mkdir", name, fs.filer.Mkdir(clean(name)))
+}
+
+// Open a file/dir for reading.
+func (fs *Fs) Open(name string) (afero.File, error) {
+	f, err := fs.hfs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &file{fs: fs, name: name, File: f}, nil
+}
+
+// OpenFile opens a file with given flags. O_APPEND is not supported.
+func (fs *Fs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) == 0 {
+		return fs.Open(name)
+	}
+
+	if flag&os.O_APPEND != 0 {
+		return nil, &os.PathError{Op: "open", Path: name, Err: ErrNotSupported}
+	}
+
+	var opts []goseaweedfs.UploadOption
+	if flag&os.O_CREATE == 0 {
+		if _, err := fs.Stat(name); err != nil {
+			return nil, err
+		}
+	} else if flag&os.O_EXCL != 0 {
+		opts = append(opts, goseaweedfs.WithExclusiveCreate())
+	}
+
+	return newWriter(fs, name, opts), nil
+}
+
+// Remove a file or an empty directory.
+func (fs *Fs) Remove(name string) error {
+	fi, err := fs.Stat(name)
+	if err != nil {
+		return err
+	}
+
+	if fi.IsDir() {
+		entries, err := fs.filer.ListDir(clean(name))
+		if err != nil {
+			return pathError("remove", name, err)
+		}
+		if len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+
+	return pathError("remove", name, fs.filer.Delete(clean(name), nil))
+}
+
+// RemoveAll removes path and any children it contains.
+func (fs *Fs) RemoveAll(name string) error {
+	return pathError("remove", name, fs.filer.Delete(clean(name), map[string][]string{"recursive": {"true"}}))
+}
+
+// Rename a file/dir.
+func (fs *Fs) Rename(oldname, newname string) error {
+	return pathError("rename", oldname, fs.filer.Rename(clean(oldname), clean(newname)))
+}
+
+// Stat returns file info of named file/dir.
+func (fs *Fs) Stat(name string) (os.FileInfo, error) {
+	fi, err := fs.filer.Stat(clean(name))
+	if err != nil {
+		return nil, pathError("stat", name, err)
+	}
+	return fi, nil
+}
+
+// Chmod is not supported.
+func (fs *Fs) Chmod(name string, mode os.FileMode) error {
+	return &os.PathError{Op: "chmod", Path: name, Err: ErrNotSupported}
+}
+
+// Chown is not supported.
+func (fs *Fs) Chown(name string, uid, gid int) error {
+	return &os.PathError{Op: "chown", Path: name, Err: ErrNotSupported}
+}
+
+// Chtimes is not supported.
+func (fs *Fs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return &os.PathError{Op: "chtimes", Path: name, Err: ErrNotSupported}
+}
+
+func clean(name string) string {
+	return path.Clean("/" + name)
+}
+
+func pathError(op, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, goseaweedfs.ErrFileNotFound) {
+		err = os.ErrNotExist
+	} else if errors.Is(err, goseaweedfs.ErrAlreadyExists) {
+		err = os.ErrExist
+	}
+	return &os.PathError{Op: op, Path: name, Err: err}
+}
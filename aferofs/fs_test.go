@@ -0,0 +1,60 @@
+// The following environment variables, if set, will be used:
+//
+//   - GOSWFS_FILER_URL
+package aferofs
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ocean2811/goseaweedfs"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFs(t *testing.T) *Fs {
+	filerURL := os.Getenv("GOSWFS_FILER_URL")
+	if filerURL == "" {
+		t.Skip("Filer URL is required")
+	}
+
+	filer, err := goseaweedfs.NewFiler(filerURL, &http.Client{Timeout: 5 * time.Minute})
+	require.Nil(t, err)
+	return New(filer)
+}
+
+func TestFs(t *testing.T) {
+	fs := newTestFs(t)
+
+	require.Nil(t, afero.WriteFile(fs, "/afero/a.txt", []byte("hello afero"), 0644))
+
+	data, err := afero.ReadFile(fs, "/afero/a.txt")
+	require.Nil(t, err)
+	require.Equal(t, "hello afero", string(data))
+
+	f, err := fs.Open("/afero/a.txt")
+	require.Nil(t, err)
+	buf := make([]byte, 5)
+	_, err = f.ReadAt(buf, 6)
+	require.Nil(t, err)
+	require.Equal(t, "afero", string(buf))
+	require.Nil(t, f.Close())
+
+	require.Nil(t, fs.Rename("/afero/a.txt", "/afero/b.txt"))
+	exists, err := afero.Exists(fs, "/afero/a.txt")
+	require.Nil(t, err)
+	require.False(t, exists)
+
+	infos, err := afero.ReadDir(fs, "/afero")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(infos))
+	require.Equal(t, "b.txt", infos[0].Name())
+
+	f, err = fs.OpenFile("/afero/b.txt", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	require.Nil(t, err)
+	require.True(t, os.IsExist(f.Close()))
+
+	require.Nil(t, fs.RemoveAll("/afero"))
+}
@@ -0,0 +1,12 @@
+module github.com/ocean2811/goseaweedfs/aferofs
+
+go 1.16
+
+require (
+	github.com/ocean2811/goseaweedfs v0.0.0
+	github.com/spf13/afero v1.9.5
+	github.com/stretchr/testify v1.7.0
+)
+
+// built against the library of the same checkout
+replace github.com/ocean2811/goseaweedfs => ../
@@ -0,0 +1,111 @@
+package goseaweedfs
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// loadDecay is the weight of a new latency sample in moving average.
+	loadDecay = 0.3
+
+	// loadErrorPenalty multiplies score of a host which failed recently.
+	loadErrorPenalty = 10
+)
+
+type hostLoad struct {
+	mu       sync.Mutex
+	latency  float64 // moving average, in nanoseconds
+	inflight int
+	failed   bool
+}
+
+// balancer tracks client-side latency/error statistics per host, to pick least loaded upload target.
+type balancer struct {
+	hosts sync.Map // host -> *hostLoad
+	rand  func(n int) int
+}
+
+func newBalancer() *balancer {
+	return &balancer{
+		rand: rand.Intn,
+	}
+}
+
+func (b *balancer) get(host string) *hostLoad {
+	if l, ok := b.hosts.Load(host); ok {
+		return l.(*hostLoad)
+	}
+	l, _ := b.hosts.LoadOrStore(host, &hostLoad{})
+	return l.(*hostLoad)
+}
+
+// start marks a request to host as in flight. Returned function must be called with result of request.
+// Requests canceled by caller are only dropped from in flight ones, as they say nothing about host load.
+func (b *balancer) start(host string) func(resp *http.Response, err error, canceled bool) {
+	begin := time.Now()
+
+	l := b.get(host)
+	l.mu.Lock()
+	l.inflight++
+	l.mu.Unlock()
+
+	return func(resp *http.Response, err error, canceled bool) {
+		elapsed := float64(time.Since(begin))
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		l.inflight--
+		if canceled {
+			return
+		}
+		if l.latency == 0 {
+			l.latency = elapsed
+		} else {
+			l.latency += loadDecay * (elapsed - l.latency)
+		}
+		l.failed = isFailure(resp, err)
+	}
+}
+
+// score estimates load of host, lower is better.
+func (b *balancer) score(host string) float64 {
+	v, ok := b.hosts.Load(host)
+	if !ok {
+		return 0 // unknown hosts are worth trying
+	}
+
+	l := v.(*hostLoad)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := (l.latency + 1) * float64(l.inflight+1)
+	if l.failed {
+		s *= loadErrorPenalty
+	}
+	return s
+}
+
+// pick chooses one of hosts using power of two choices: the less loaded of two random candidates.
+func (b *balancer) pick(hosts []string) string {
+	switch len(hosts) {
+	case 0:
+		return ""
+	case 1:
+		return hosts[0]
+	}
+
+	i := b.rand(len(hosts))
+	j := b.rand(len(hosts) - 1)
+	if j >= i {
+		j++
+	}
+
+	if b.score(hosts[j]) < b.score(hosts[i]) {
+		return hosts[j]
+	}
+	return hosts[i]
+}
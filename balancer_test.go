@@ -0,0 +1,52 @@
+package goseaweedfs
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalancer(t *testing.T) {
+	b := newBalancer()
+	require.Equal(t, "", b.pick(nil))
+	require.Equal(t, "a", b.pick([]string{"a"}))
+
+	// slow host
+	done := b.start("a")
+	time.Sleep(5 * time.Millisecond)
+	done(&http.Response{StatusCode: http.StatusOK}, nil, false)
+
+	// fast host
+	b.start("b")(&http.Response{StatusCode: http.StatusOK}, nil, false)
+
+	for i := 0; i < 10; i++ {
+		require.Equal(t, "b", b.pick([]string{"a", "b"}))
+	}
+
+	// failing host is penalized
+	b.start("b")(nil, errors.New("connection refused"), false)
+	b.get("b").latency = b.get("a").latency / 2
+	require.Equal(t, "a", b.pick([]string{"a", "b"}))
+
+	// unknown host is preferred
+	b.rand = func(n int) int { return 0 }
+	require.Equal(t, "c", b.pick([]string{"a", "c"}))
+}
+
+func TestPickUploadTarget(t *testing.T) {
+	res := &AssignResult{URL: "a", Replicas: []*VolumeLocation{{URL: "a"}, {URL: "b"}}}
+
+	c := newHTTPClient(nil, newOptions(nil))
+	require.Equal(t, "a", c.pickUploadTarget(res))
+	_ = c.Close()
+
+	c = newHTTPClient(nil, newOptions([]Option{WithLeastLoaded()}))
+	defer func() { _ = c.Close() }()
+	c.balancer.start("a")(nil, errors.New("connection refused"), false)
+	c.balancer.start("b")(&http.Response{StatusCode: http.StatusOK}, nil, false)
+	c.balancer.get("a").latency = c.balancer.get("b").latency
+	require.Equal(t, "b", c.pickUploadTarget(res))
+}
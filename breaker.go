@@ -0,0 +1,171 @@
+package goseaweedfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen return when requests to a host are rejected because its circuit breaker is open.
+var ErrCircuitOpen = fmt.Errorf("Circuit breaker is open")
+
+// BreakerState state of circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed requests pass through.
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen requests fail fast.
+	BreakerOpen
+
+	// BreakerHalfOpen a single probe request is allowed to check if host recovered.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerStateHook is called when circuit breaker of host changes state.
+type BreakerStateHook func(host string, from, to BreakerState)
+
+type circuitBreaker struct {
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// breakers tracks consecutive failures per target host.
+type breakers struct {
+	threshold int
+	cooldown  time.Duration
+	hook      BreakerStateHook
+	now       func() time.Time
+
+	hosts sync.Map // host -> *circuitBreaker
+}
+
+func newBreakers(threshold int, cooldown time.Duration, hook BreakerStateHook) *breakers {
+	return &breakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		hook:      hook,
+		now:       time.Now,
+	}
+}
+
+func (b *breakers) get(host string) *circuitBreaker {
+	if cb, ok := b.hosts.Load(host); ok {
+		return cb.(*circuitBreaker)
+	}
+	cb, _ := b.hosts.LoadOrStore(host, &circuitBreaker{})
+	return cb.(*circuitBreaker)
+}
+
+// allow checks if a request to host could be sent.
+func (b *breakers) allow(host string) error {
+	cb := b.get(host)
+
+	cb.mu.Lock()
+	from := cb.state
+	switch cb.state {
+	case BreakerOpen:
+		if b.now().Sub(cb.openedAt) < b.cooldown {
+			cb.mu.Unlock()
+			return ErrCircuitOpen
+		}
+		cb.state, cb.probing = BreakerHalfOpen, true
+
+	case BreakerHalfOpen:
+		if cb.probing {
+			cb.mu.Unlock()
+			return ErrCircuitOpen
+		}
+		cb.probing = true
+	}
+	to := cb.state
+	cb.mu.Unlock()
+
+	b.notify(host, from, to)
+	return nil
+}
+
+// release probe slot of host without judging its health, e.g. when request was canceled by caller.
+func (b *breakers) release(host string) {
+	cb := b.get(host)
+
+	cb.mu.Lock()
+	cb.probing = false
+	cb.mu.Unlock()
+}
+
+// record result of a request to host.
+func (b *breakers) record(host string, success bool) {
+	cb := b.get(host)
+
+	cb.mu.Lock()
+	from := cb.state
+	cb.probing = false
+	if success {
+		cb.state, cb.failures = BreakerClosed, 0
+	} else {
+		cb.failures++
+		if cb.state == BreakerHalfOpen || cb.failures >= b.threshold {
+			cb.state, cb.openedAt = BreakerOpen, b.now()
+		}
+	}
+	to := cb.state
+	cb.mu.Unlock()
+
+	b.notify(host, from, to)
+}
+
+func (b *breakers) notify(host string, from, to BreakerState) {
+	if from != to && b.hook != nil {
+		b.hook(host, from, to)
+	}
+}
+
+func (b *breakers) states() map[string]BreakerState {
+	result := make(map[string]BreakerState)
+	b.hosts.Range(func(host, v interface{}) bool {
+		cb := v.(*circuitBreaker)
+		cb.mu.Lock()
+		result[host.(string)] = cb.state
+		cb.mu.Unlock()
+		return true
+	})
+	return result
+}
+
+// canceledByCaller reports whether request failed because caller gave up on it (e.g. a hedged request losing
+// the race), which says nothing about health of target host.
+func canceledByCaller(req *http.Request, err error) bool {
+	return err != nil && (req.Context().Err() != nil || errors.Is(err, context.Canceled))
+}
+
+// isFailure reports whether a response means target host is unhealthy.
+func isFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package goseaweedfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakers(t *testing.T) {
+	var transitions []string
+	b := newBreakers(2, time.Second, func(host string, from, to BreakerState) {
+		transitions = append(transitions, host+":"+from.String()+"->"+to.String())
+	})
+
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	host := "localhost:8080"
+	require.Nil(t, b.allow(host))
+	b.record(host, false)
+	require.Nil(t, b.allow(host))
+	b.record(host, false)
+
+	// opened after threshold consecutive failures
+	require.Equal(t, ErrCircuitOpen, b.allow(host))
+	require.Equal(t, BreakerOpen, b.states()[host])
+
+	// half-open after cooldown, only one probe allowed
+	now = now.Add(time.Second)
+	require.Nil(t, b.allow(host))
+	require.Equal(t, ErrCircuitOpen, b.allow(host))
+
+	// failed probe opens again
+	b.record(host, false)
+	require.Equal(t, ErrCircuitOpen, b.allow(host))
+
+	// successful probe closes
+	now = now.Add(time.Second)
+	require.Nil(t, b.allow(host))
+	b.record(host, true)
+	require.Equal(t, BreakerClosed, b.states()[host])
+	require.Nil(t, b.allow(host))
+
+	require.Equal(t, []string{
+		"localhost:8080:closed->open",
+		"localhost:8080:open->half-open",
+		"localhost:8080:half-open->open",
+		"localhost:8080:open->half-open",
+		"localhost:8080:half-open->closed",
+	}, transitions)
+
+	// success resets consecutive failures
+	b.record("other:8080", false)
+	b.record("other:8080", true)
+	b.record("other:8080", false)
+	require.Equal(t, BreakerClosed, b.states()["other:8080"])
+}
+
+func TestBreakerWithHedging(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	var fast *httptest.Server
+	fast = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dir/lookup" {
+			slowHost, fastHost := slow.Listener.Addr().String(), fast.Listener.Addr().String()
+			fmt.Fprintf(w, `{"locations":[{"url":%q,"publicUrl":%q},{"url":%q,"publicUrl":%q}]}`, slowHost, slowHost, fastHost, fastHost)
+			return
+		}
+		fmt.Fprint(w, "content")
+	}))
+	defer fast.Close()
+
+	sw, err := NewSeaweed(fast.URL, nil, 1024, fast.Client(),
+		WithHedging(0.5, 5*time.Millisecond), WithCircuitBreaker(1, time.Minute), WithLeastLoaded())
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	// losing attempts to slow replica are canceled on purpose, which must not count against it
+	for i := 0; i < 10; i++ {
+		_, err = sw.Download("3,01637037d6", nil, func(r io.Reader) error {
+			_, err := ioutil.ReadAll(r)
+			return err
+		})
+		require.Nil(t, err)
+	}
+
+	slowHost := slow.Listener.Addr().String()
+	require.Eventually(t, func() bool {
+		l := sw.client.balancer.get(slowHost)
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.inflight == 0
+	}, time.Second, time.Millisecond)
+	require.Equal(t, BreakerClosed, sw.BreakerStates()[slowHost])
+	require.False(t, sw.client.balancer.get(slowHost).failed)
+
+	// neither are requests abandoned by caller
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, (&url.URL{Scheme: "http", Host: slowHost, Path: "/x"}).String(), nil)
+	require.Nil(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = sw.client.do(req)
+		require.NotNil(t, err)
+	}
+	require.Equal(t, BreakerClosed, sw.BreakerStates()[slowHost])
+}
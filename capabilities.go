@@ -0,0 +1,145 @@
+package goseaweedfs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// ErrUnsupportedByServer returned when a feature is not available on the server version.
+var ErrUnsupportedByServer = fmt.Errorf("Unsupported by server")
+
+// ServerVersion version of a SeaweedFS server, e.g. "30GB 3.59 9ac4d5a" reported as 3.59.
+type ServerVersion struct {
+	Major int
+	Minor int
+
+	// Raw version string as reported by server.
+	Raw string
+}
+
+var serverVersionPattern = regexp.MustCompile(`\b(\d+)\.(\d+)\b`)
+
+// ParseServerVersion extracts version number from version string reported by server (master status or
+// Server header), e.g. "SeaweedFS Filer 30GB 3.59".
+func ParseServerVersion(raw string) (v ServerVersion) {
+	v.Raw = raw
+	if m := serverVersionPattern.FindStringSubmatch(raw); m != nil {
+		v.Major, _ = strconv.Atoi(m[1])
+		v.Minor, _ = strconv.Atoi(m[2])
+	}
+	return
+}
+
+// Known reports whether version number could be parsed.
+func (v ServerVersion) Known() bool {
+	return v.Major > 0 || v.Minor > 0
+}
+
+// AtLeast reports whether version is at least major.minor. Unknown version is assumed recent enough.
+func (v ServerVersion) AtLeast(major, minor int) bool {
+	if !v.Known() {
+		return true
+	}
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
+
+func (v ServerVersion) String() string {
+	if !v.Known() {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// First server versions supporting features. They are approximate, so they are never used to block a request:
+// requests are sent as is, and capabilities only explain why server rejected one (see Filer.explainRejection).
+// The exception is conditional create, which an older filer would not reject but silently overwrite.
+var (
+	versionTagging           = ServerVersion{Major: 2, Minor: 13}
+	versionMove              = ServerVersion{Major: 2, Minor: 57}
+	versionConditionalCreate = ServerVersion{Major: 3, Minor: 80}
+)
+
+// Capabilities features supported by server, as derived from its version. Servers of unknown version are
+// assumed to support everything. Derived versions are approximate, treat them as hints.
+type Capabilities struct {
+	Version ServerVersion
+
+	// Tagging updating custom metadata (Seaweed- headers) of filer entries in place.
+	Tagging bool
+	// Move server side rename of filer entries (mv.from).
+	Move bool
+	// ConditionalCreate honoring If-None-Match: * on filer uploads, see WithExclusiveCreate.
+	ConditionalCreate bool
+}
+
+func newCapabilities(v ServerVersion) *Capabilities {
+	supports := func(min ServerVersion) bool { return v.AtLeast(min.Major, min.Minor) }
+	return &Capabilities{
+		Version:           v,
+		Tagging:           supports(versionTagging),
+		Move:              supports(versionMove),
+		ConditionalCreate: supports(versionConditionalCreate),
+	}
+}
+
+// capabilityCache detects capabilities once. Failed detections are retried on next use.
+type capabilityCache struct {
+	mu   sync.Mutex
+	caps *Capabilities
+}
+
+func (c *capabilityCache) get(detect func() (ServerVersion, error)) (caps *Capabilities, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.caps == nil {
+		var v ServerVersion
+		if v, err = detect(); err != nil {
+			return
+		}
+		c.caps = newCapabilities(v)
+	}
+	return c.caps, nil
+}
+
+// Capabilities detects features supported by master, from version reported by its status. Detected on first call,
+// then cached.
+func (c *Seaweed) Capabilities(ctx context.Context) (*Capabilities, error) {
+	return c.capabilities.get(func() (v ServerVersion, err error) {
+		status := &SystemStatus{}
+		if _, err = c.client.getJSONContext(ctx, encodeURI(c.masterURL(), "/dir/status", nil), nil, status); err == nil {
+			v = ParseServerVersion(status.Version)
+		}
+		return
+	})
+}
+
+// Capabilities detects features supported by filer, from version reported by its Server header. Detected on first
+// call, then cached.
+func (f *Filer) Capabilities(ctx context.Context) (*Capabilities, error) {
+	return f.capabilities.get(func() (v ServerVersion, err error) {
+		var header http.Header
+		if _, header, err = f.client.probe(ctx, http.MethodHead, encodeURI(*f.base, "/", nil)); err == nil {
+			v = ParseServerVersion(header.Get("Server"))
+		}
+		return
+	})
+}
+
+// explainRejection turns err of a request rejected as unknown (404/405) into ErrUnsupportedByServer, if filer
+// is too old to support feature. Any other error, or failed detection, is returned as is.
+func (f *Filer) explainRejection(feature string, statusCode int, err error, supported func(*Capabilities) bool) error {
+	if err == nil || (statusCode != http.StatusNotFound && statusCode != http.StatusMethodNotAllowed) {
+		return err
+	}
+
+	caps, e := f.Capabilities(context.Background())
+	if e == nil && !supported(caps) {
+		return fmt.Errorf("%w: %s needs a newer filer than %s: %v", ErrUnsupportedByServer, feature, caps.Version, err)
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package goseaweedfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	v := ParseServerVersion("SeaweedFS Filer 30GB 3.59")
+	require.Equal(t, 3, v.Major)
+	require.Equal(t, 59, v.Minor)
+	require.Equal(t, "3.59", v.String())
+	require.True(t, v.AtLeast(2, 57))
+	require.True(t, v.AtLeast(3, 59))
+	require.False(t, v.AtLeast(3, 60))
+	require.False(t, ParseServerVersion("1.9").AtLeast(1, 44))
+	require.True(t, ParseServerVersion("30GB 2.01 abc").AtLeast(1, 44))
+
+	v = ParseServerVersion("SeaweedFS Filer")
+	require.False(t, v.Known())
+	require.True(t, v.AtLeast(9, 99))
+	require.Equal(t, "unknown", v.String())
+}
+
+func TestSeaweedCapabilities(t *testing.T) {
+	sw, done := newTestMaster(t, map[string]string{"/dir/status": `{"Version":"30GB 1.50 abc"}`})
+	defer done()
+
+	caps, err := sw.Capabilities(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, "1.50", caps.Version.String())
+	require.False(t, caps.Tagging)
+	require.False(t, caps.Move)
+	require.False(t, caps.ConditionalCreate)
+}
+
+func TestFilerUnsupportedByServer(t *testing.T) {
+	var heads, requests int
+	version := "SeaweedFS Filer 2.10"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", version)
+		if r.Method == http.MethodHead {
+			heads++
+			return
+		}
+		requests++
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	// requests are sent, rejection is explained by server version
+	err = filer.Rename("/a.txt", "/b.txt")
+	require.True(t, errors.Is(err, ErrUnsupportedByServer), "%v", err)
+
+	err = filer.SetTags("/a.txt", map[string]string{"k": "v"})
+	require.True(t, errors.Is(err, ErrUnsupportedByServer), "%v", err)
+
+	require.Equal(t, 2, requests)
+	require.Equal(t, 1, heads)
+
+	// rejection by a recent filer is reported as is
+	version = "SeaweedFS Filer 3.59"
+	recent, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = recent.Close() }()
+
+	err = recent.Rename("/a.txt", "/b.txt")
+	require.NotNil(t, err)
+	require.False(t, errors.Is(err, ErrUnsupportedByServer))
+}
@@ -0,0 +1,141 @@
+package goseaweedfs
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// DefaultClusterPollInterval is the default interval of polling cluster status, see WatchCluster.
+const DefaultClusterPollInterval = 10 * time.Second
+
+// ClusterEventType type of cluster membership change.
+type ClusterEventType int
+
+const (
+	// LeaderChanged master leader changed.
+	LeaderChanged ClusterEventType = iota
+	// VolumeServerUp volume server joined topology.
+	VolumeServerUp
+	// VolumeServerDown volume server left topology.
+	VolumeServerDown
+)
+
+func (t ClusterEventType) String() string {
+	switch t {
+	case LeaderChanged:
+		return "leader-changed"
+	case VolumeServerUp:
+		return "volume-server-up"
+	case VolumeServerDown:
+		return "volume-server-down"
+	}
+	return "unknown"
+}
+
+// ClusterEvent a change of cluster membership.
+type ClusterEvent struct {
+	Type ClusterEventType
+	Time time.Time
+
+	// Leader and PreviousLeader addresses of master leader, for LeaderChanged.
+	Leader         string
+	PreviousLeader string
+
+	// Server url of volume server with its location, for VolumeServerUp/VolumeServerDown.
+	Server     string
+	DataCenter string
+	Rack       string
+}
+
+// ClusterEventHook is called with every cluster event.
+type ClusterEventHook func(ClusterEvent)
+
+// clusterState membership snapshot of cluster.
+type clusterState struct {
+	leader  string
+	servers map[string]ClusterEvent
+}
+
+// WatchCluster polls master every interval (DefaultClusterPollInterval if not positive) and calls hook with
+// every change of leader and volume servers, e.g. to warm caches or rebalance writes. The first poll only
+// establishes the baseline, and failed polls are skipped. Blocks until ctx is done, returning its error.
+func (c *Seaweed) WatchCluster(ctx context.Context, interval time.Duration, hook ClusterEventHook) error {
+	if interval <= 0 {
+		interval = DefaultClusterPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last *clusterState
+	for {
+		if state, err := c.clusterState(ctx); err == nil {
+			if last != nil {
+				for _, e := range last.diff(state) {
+					hook(e)
+				}
+			}
+			last = state
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Seaweed) clusterState(ctx context.Context) (state *clusterState, err error) {
+	master := c.masterURL()
+
+	cluster := &ClusterStatus{}
+	if _, err = c.client.getJSONContext(ctx, encodeURI(master, "/cluster/status", nil), nil, cluster); err != nil {
+		return
+	}
+
+	status := &SystemStatus{}
+	if _, err = c.client.getJSONContext(ctx, encodeURI(master, "/dir/status", nil), nil, status); err != nil {
+		return
+	}
+
+	state = &clusterState{leader: cluster.Leader, servers: make(map[string]ClusterEvent)}
+	for _, dc := range status.Topology.DataCenters {
+		for _, rack := range dc.Racks {
+			for _, node := range rack.DataNodes {
+				state.servers[node.URL] = ClusterEvent{Server: node.URL, DataCenter: dc.ID, Rack: rack.ID}
+			}
+		}
+	}
+	return
+}
+
+// diff returns events changing s into next.
+func (s *clusterState) diff(next *clusterState) (events []ClusterEvent) {
+	now := time.Now()
+
+	if next.leader != s.leader {
+		events = append(events, ClusterEvent{Type: LeaderChanged, Time: now, Leader: next.leader, PreviousLeader: s.leader})
+	}
+	for url, e := range next.servers {
+		if _, ok := s.servers[url]; !ok {
+			e.Type, e.Time = VolumeServerUp, now
+			events = append(events, e)
+		}
+	}
+	for url, e := range s.servers {
+		if _, ok := next.servers[url]; !ok {
+			e.Type, e.Time = VolumeServerDown, now
+			events = append(events, e)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Type != events[j].Type {
+			return events[i].Type < events[j].Type
+		}
+		return events[i].Server < events[j].Server
+	})
+	return
+}
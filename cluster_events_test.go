@@ -0,0 +1,93 @@
+package goseaweedfs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterStateDiff(t *testing.T) {
+	last := &clusterState{leader: "m1:9333", servers: map[string]ClusterEvent{
+		"v1:8080": {Server: "v1:8080"},
+		"v2:8080": {Server: "v2:8080"},
+	}}
+	next := &clusterState{leader: "m2:9333", servers: map[string]ClusterEvent{
+		"v2:8080": {Server: "v2:8080"},
+		"v4:8080": {Server: "v4:8080", DataCenter: "dc1", Rack: "r1"},
+		"v3:8080": {Server: "v3:8080"},
+	}}
+
+	events := last.diff(next)
+	require.Len(t, events, 4)
+	require.Equal(t, LeaderChanged, events[0].Type)
+	require.Equal(t, "m2:9333", events[0].Leader)
+	require.Equal(t, "m1:9333", events[0].PreviousLeader)
+	require.Equal(t, VolumeServerUp, events[1].Type)
+	require.Equal(t, "v3:8080", events[1].Server)
+	require.Equal(t, "v4:8080", events[2].Server)
+	require.Equal(t, "dc1", events[2].DataCenter)
+	require.Equal(t, VolumeServerDown, events[3].Type)
+	require.Equal(t, "v1:8080", events[3].Server)
+
+	require.Empty(t, next.diff(next))
+	require.Equal(t, "volume-server-down", VolumeServerDown.String())
+}
+
+func TestWatchCluster(t *testing.T) {
+	var mu sync.Mutex
+	leader, servers := "m1:9333", []string{"v1:8080"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/cluster/status":
+			fmt.Fprintf(w, `{"IsLeader":true,"Leader":%q}`, leader)
+		case "/dir/status":
+			nodes := make([]string, 0, len(servers))
+			for _, s := range servers {
+				nodes = append(nodes, fmt.Sprintf(`{"Url":%q}`, s))
+			}
+			fmt.Fprintf(w, `{"Topology":{"DataCenters":[{"Id":"dc1","Racks":[{"Id":"r1","DataNodes":[%s]}]}]}}`, strings.Join(nodes, ","))
+		}
+	}))
+	defer server.Close()
+
+	sw, err := NewSeaweed(server.URL, nil, 1024, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	events := make(chan ClusterEvent, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- sw.WatchCluster(ctx, 5*time.Millisecond, func(e ClusterEvent) { events <- e }) }()
+
+	// let baseline be established, then change topology
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	leader, servers = "m2:9333", []string{"v2:8080"}
+	mu.Unlock()
+
+	var got []string
+	for len(got) < 3 {
+		select {
+		case e := <-events:
+			got = append(got, e.Type.String()+" "+e.Leader+e.Server)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("missing events, got %v", got)
+		}
+	}
+	require.Equal(t, []string{"leader-changed m2:9333", "volume-server-up v2:8080", "volume-server-down v1:8080"}, got)
+
+	cancel()
+	require.Equal(t, context.Canceled, <-done)
+	require.Empty(t, events)
+}
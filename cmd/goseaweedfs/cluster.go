@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+
+	"github.com/ocean2811/goseaweedfs"
+	"github.com/spf13/cobra"
+)
+
+// clusterStatus cluster-status output.
+type clusterStatus struct {
+	Health   *goseaweedfs.Health
+	Cluster  *goseaweedfs.ClusterStatus `json:",omitempty"`
+	Topology *goseaweedfs.Topology      `json:",omitempty"`
+}
+
+func newClusterStatusCmd(cfg *config) *cobra.Command {
+	var topology bool
+
+	cmd := &cobra.Command{
+		Use:   "cluster-status",
+		Short: "Print master health, leader and optionally topology as JSON",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sw, err := cfg.newSeaweed()
+			if err != nil {
+				return err
+			}
+			defer func() { _ = sw.Close() }()
+
+			// health is printed even if master is unhealthy
+			status := &clusterStatus{}
+			status.Health, err = sw.Ping(context.Background())
+			if err == nil {
+				status.Cluster, err = sw.ClusterStatus()
+			}
+			if err == nil && topology {
+				var system *goseaweedfs.SystemStatus
+				if system, err = sw.Status(); err == nil {
+					status.Topology = &system.Topology
+				}
+			}
+
+			if e := printJSON(cmd.OutOrStdout(), status); err == nil {
+				err = e
+			}
+			return err
+		},
+	}
+	cmd.Flags().BoolVar(&topology, "topology", false, "include data centers, racks and volume servers")
+	return cmd
+}
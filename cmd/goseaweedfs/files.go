@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/ocean2811/goseaweedfs"
+	"github.com/spf13/cobra"
+)
+
+func newUploadCmd(cfg *config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "upload <local file> [filer path]",
+		Short: "Upload a file to filer path, or to a volume server printing its file id",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			local := args[0]
+			if len(args) == 1 {
+				sw, err := cfg.newSeaweed()
+				if err != nil {
+					return err
+				}
+				defer func() { _ = sw.Close() }()
+
+				_, fp, err := sw.UploadFile(local, cfg.collection, cfg.ttl)
+				if err == nil {
+					printf(cmd.OutOrStdout(), "%s\n", fp.FileID)
+				}
+				return err
+			}
+
+			remote := args[1]
+			if !isFilerPath(remote) {
+				return fmt.Errorf("filer path must start with /: %s", remote)
+			}
+			if remote[len(remote)-1] == '/' {
+				remote = path.Join(remote, filepath.Base(local))
+			}
+
+			filer, err := cfg.newFiler()
+			if err != nil {
+				return err
+			}
+			defer func() { _ = filer.Close() }()
+
+			result, err := filer.UploadFile(local, remote, cfg.collection, cfg.ttl)
+			if err == nil {
+				printf(cmd.OutOrStdout(), "%s\t%d\n", remote, result.Size)
+			}
+			return err
+		},
+	}
+}
+
+func newDownloadCmd(cfg *config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "download <file id | filer path> [local file]",
+		Short: "Download a file, to stdout if local file is omitted or -",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			var r *goseaweedfs.DownloadResult
+			if isFilerPath(args[0]) {
+				var filer *goseaweedfs.Filer
+				if filer, err = cfg.newFiler(); err != nil {
+					return
+				}
+				defer func() { _ = filer.Close() }()
+				r, err = filer.Fetch(args[0], nil, nil)
+			} else {
+				var sw *goseaweedfs.Seaweed
+				if sw, err = cfg.newSeaweed(); err != nil {
+					return
+				}
+				defer func() { _ = sw.Close() }()
+				r, err = sw.Fetch(args[0], nil, nil)
+			}
+			if err != nil {
+				return
+			}
+			defer func() { _ = r.Close() }()
+
+			out := cmd.OutOrStdout()
+			if len(args) == 2 && args[1] != "-" {
+				var f *os.File
+				if f, err = os.Create(args[1]); err != nil {
+					return
+				}
+				defer func() {
+					if e := f.Close(); err == nil {
+						err = e
+					}
+				}()
+				out = f
+			}
+
+			_, err = io.Copy(out, r.Body)
+			return
+		},
+	}
+}
+
+func newRmCmd(cfg *config) *cobra.Command {
+	var recursive bool
+
+	cmd := &cobra.Command{
+		Use:   "rm <file id | filer path>...",
+		Short: "Delete files",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			var sw *goseaweedfs.Seaweed
+			var filer *goseaweedfs.Filer
+			defer func() {
+				if sw != nil {
+					_ = sw.Close()
+				}
+				if filer != nil {
+					_ = filer.Close()
+				}
+			}()
+
+			for _, target := range args {
+				if isFilerPath(target) {
+					if filer == nil {
+						if filer, err = cfg.newFiler(); err != nil {
+							return
+						}
+					}
+					var opts []goseaweedfs.DeleteOption
+					if recursive {
+						opts = append(opts, goseaweedfs.WithRecursive())
+					}
+					_, err = filer.DeleteWithOptions(target, opts...)
+				} else {
+					if sw == nil {
+						if sw, err = cfg.newSeaweed(); err != nil {
+							return
+						}
+					}
+					// chunks of a chunk manifested file go along, rather than being orphaned
+					_, err = sw.DeleteFileWithOptions(target, nil, goseaweedfs.WithCascadeChunks())
+				}
+				if err != nil {
+					return fmt.Errorf("rm %s: %w", target, err)
+				}
+			}
+			return
+		},
+	}
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "delete directories recursively")
+	return cmd
+}
+
+// fileStat stat output of a volume file.
+type fileStat struct {
+	FileID   string
+	Name     string
+	Size     int64
+	Metadata map[string]string `json:",omitempty"`
+}
+
+func newStatCmd(cfg *config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "stat <file id | filer path>",
+		Short: "Print metadata of a file as JSON",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if isFilerPath(args[0]) {
+				filer, err := cfg.newFiler()
+				if err != nil {
+					return err
+				}
+				defer func() { _ = filer.Close() }()
+
+				fi, err := filer.Stat(args[0])
+				if err != nil {
+					return err
+				}
+				return printJSON(cmd.OutOrStdout(), fi)
+			}
+
+			sw, err := cfg.newSeaweed()
+			if err != nil {
+				return err
+			}
+			defer func() { _ = sw.Close() }()
+
+			name, size, md, err := sw.Preview(args[0], nil)
+			if err != nil {
+				return err
+			}
+			return printJSON(cmd.OutOrStdout(), &fileStat{FileID: args[0], Name: name, Size: size, Metadata: md})
+		},
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/ocean2811/goseaweedfs"
+	"github.com/spf13/cobra"
+)
+
+func newLsCmd(cfg *config) *cobra.Command {
+	var recursive bool
+
+	cmd := &cobra.Command{
+		Use:   "ls [filer dir]",
+		Short: "List a filer directory",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "/"
+			if len(args) == 1 {
+				dir = args[0]
+			}
+
+			filer, err := cfg.newFiler()
+			if err != nil {
+				return err
+			}
+			defer func() { _ = filer.Close() }()
+
+			out := cmd.OutOrStdout()
+			if !recursive {
+				entries, err := filer.ListDir(dir)
+				for _, fi := range entries {
+					printEntry(out, fi)
+				}
+				return err
+			}
+
+			root := "/" + strings.Trim(dir, "/")
+			return filer.Walk(context.Background(), dir, func(p string, fi *goseaweedfs.FileInfo, err error) error {
+				if err != nil || p == root {
+					return err
+				}
+				printEntry(out, fi)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "list subdirectories recursively")
+	return cmd
+}
+
+func printEntry(w io.Writer, fi *goseaweedfs.FileInfo) {
+	name := fi.FullPath
+	if fi.IsDir() {
+		name += "/"
+	}
+	printf(w, "%s\t%12d\t%s\t%s\n", fi.Mode(), fi.Size(), fi.ModTime().Format("2006-01-02 15:04:05"), name)
+}
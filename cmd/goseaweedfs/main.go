@@ -0,0 +1,103 @@
+// Command goseaweedfs is a small SeaweedFS command line tool built on github.com/ocean2811/goseaweedfs.
+//
+// Paths starting with "/" address filer entries, anything else is a volume file id:
+//
+//	goseaweedfs upload photo.jpg                 # to volume server, prints file id
+//	goseaweedfs upload photo.jpg /photos/a.jpg   # to filer
+//	goseaweedfs download /photos/a.jpg a.jpg
+//	goseaweedfs ls -r /photos
+//	goseaweedfs sync ./photos /photos
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ocean2811/goseaweedfs"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// config global flags shared by commands.
+type config struct {
+	master     string
+	filer      string
+	collection string
+	ttl        string
+	chunkSize  int64
+	timeout    time.Duration
+}
+
+func newRootCmd() *cobra.Command {
+	cfg := &config{}
+
+	root := &cobra.Command{
+		Use:           "goseaweedfs",
+		Short:         "SeaweedFS command line client",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&cfg.master, "master", envOr("GOSWFS_MASTER_URL", "http://localhost:9333"), "master url")
+	flags.StringVar(&cfg.filer, "filer", envOr("GOSWFS_FILER_URL", "http://localhost:8888"), "filer url")
+	flags.StringVar(&cfg.collection, "collection", "", "collection of uploaded files")
+	flags.StringVar(&cfg.ttl, "ttl", "", "time to live of uploaded files, e.g. 3d")
+	flags.Int64Var(&cfg.chunkSize, "chunk-size", 8<<20, "chunk size of large files uploaded to volume servers")
+	flags.DurationVar(&cfg.timeout, "timeout", 0, "http client timeout, 0 means no timeout")
+
+	root.AddCommand(
+		newUploadCmd(cfg),
+		newDownloadCmd(cfg),
+		newLsCmd(cfg),
+		newRmCmd(cfg),
+		newStatCmd(cfg),
+		newSyncCmd(cfg),
+		newClusterStatusCmd(cfg),
+	)
+	return root
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// isFilerPath reports whether target is a filer path rather than a file id.
+func isFilerPath(target string) bool {
+	return strings.HasPrefix(target, "/")
+}
+
+func (c *config) httpClient() *http.Client {
+	return &http.Client{Timeout: c.timeout}
+}
+
+func (c *config) newSeaweed() (*goseaweedfs.Seaweed, error) {
+	return goseaweedfs.NewSeaweed(c.master, nil, c.chunkSize, c.httpClient())
+}
+
+func (c *config) newFiler(opts ...goseaweedfs.Option) (*goseaweedfs.Filer, error) {
+	return goseaweedfs.NewFiler(c.filer, c.httpClient(), opts...)
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printf(w io.Writer, format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(w, format, args...)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ocean2811/goseaweedfs"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeFiler serves uploads, downloads, metadata and listings from memory.
+func newFakeFiler(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	files := make(map[string][]byte)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		p := path.Clean(r.URL.Path)
+		data, isFile := files[p]
+		switch {
+		case r.Method == http.MethodPost:
+			f, _, err := r.FormFile("file")
+			require.Nil(t, err)
+			data, _ = ioutil.ReadAll(f)
+			files[p] = data
+			_ = json.NewEncoder(w).Encode(&goseaweedfs.FilerUploadResult{Name: path.Base(p), Size: int64(len(data))})
+		case isFile && r.URL.Query().Get("metadata") == "true":
+			_ = json.NewEncoder(w).Encode(&goseaweedfs.FileInfo{FullPath: p, FileSize: uint64(len(data)), Mtime: time.Now(), FileMode: 0644})
+		case isFile:
+			_, _ = w.Write(data)
+		case strings.HasSuffix(r.URL.Path, "/"):
+			listing := &goseaweedfs.FilerListing{Path: p}
+			for name, data := range files {
+				if path.Dir(name) == p {
+					listing.Entries = append(listing.Entries, &goseaweedfs.FileInfo{FullPath: name, FileSize: uint64(len(data)), FileMode: 0644})
+				}
+			}
+			sort.Slice(listing.Entries, func(i, j int) bool { return listing.Entries[i].FullPath < listing.Entries[j].FullPath })
+			_ = json.NewEncoder(w).Encode(listing)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func run(t *testing.T, filer string, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := newRootCmd()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(append([]string{"--filer", filer, "--master", "http://127.0.0.1:1"}, args...))
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestCLI(t *testing.T) {
+	filer := newFakeFiler(t).URL
+
+	dir := t.TempDir()
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0600))
+	require.Nil(t, os.Mkdir(filepath.Join(dir, "sub"), 0700))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("world"), 0600))
+
+	out, err := run(t, filer, "upload", filepath.Join(dir, "a.txt"), "/docs/")
+	require.Nil(t, err)
+	require.Equal(t, "/docs/a.txt\t5\n", out)
+
+	out, err = run(t, filer, "download", "/docs/a.txt")
+	require.Nil(t, err)
+	require.Equal(t, "hello", out)
+
+	local := filepath.Join(dir, "downloaded.txt")
+	_, err = run(t, filer, "download", "/docs/a.txt", local)
+	require.Nil(t, err)
+	data, _ := ioutil.ReadFile(local)
+	require.Equal(t, "hello", string(data))
+
+	out, err = run(t, filer, "stat", "/docs/a.txt")
+	require.Nil(t, err)
+	var fi goseaweedfs.FileInfo
+	require.Nil(t, json.Unmarshal([]byte(out), &fi))
+	require.EqualValues(t, 5, fi.Size())
+
+	out, err = run(t, filer, "ls", "/docs")
+	require.Nil(t, err)
+	require.Contains(t, out, "/docs/a.txt")
+
+	// a.txt is up to date remotely, the rest is missing
+	out, err = run(t, filer, "sync", "--dry-run", dir, "/docs")
+	require.Nil(t, err)
+	require.Contains(t, out, "-> /docs/downloaded.txt\n")
+	require.Contains(t, out, "-> /docs/sub/b.txt\n")
+	require.Contains(t, out, "2 uploaded, 1 up to date\n")
+	_, err = run(t, filer, "download", "/docs/sub/b.txt")
+	require.NotNil(t, err)
+
+	_, err = run(t, filer, "sync", dir, "/docs")
+	require.Nil(t, err)
+	out, err = run(t, filer, "download", "/docs/sub/b.txt")
+	require.Nil(t, err)
+	require.Equal(t, "world", out)
+
+	_, err = run(t, filer, "download", "/docs/missing.txt")
+	require.NotNil(t, err)
+	_, err = run(t, filer, "upload", filepath.Join(dir, "a.txt"), "docs/a.txt")
+	require.NotNil(t, err)
+}
+
+func TestRmChunked(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/dir/lookup":
+			addr := server.Listener.Addr().String()
+			_, _ = fmt.Fprintf(w, `{"locations":[{"url":%q,"publicUrl":%q}]}`, addr, addr)
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+		case r.URL.Query().Get("cm") == "false":
+			_, _ = fmt.Fprint(w, `{"name":"big","size":16,"chunks":[{"fid":"3,02","size":8},{"fid":"3,03","offset":8,"size":8}]}`)
+		default:
+			w.Header().Set("X-File-Store", "chunked")
+		}
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	cmd := newRootCmd()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"--master", server.URL, "rm", "3,01"})
+	require.Nil(t, cmd.Execute())
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(deleted)
+	require.Equal(t, []string{"/3,01", "/3,02", "/3,03"}, deleted)
+}
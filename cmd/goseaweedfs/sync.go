@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/ocean2811/goseaweedfs"
+	"github.com/spf13/cobra"
+)
+
+func newSyncCmd(cfg *config) *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "sync <local dir> <filer dir>",
+		Short: "Upload files of local dir which are missing or changed in filer dir",
+		Long: "Upload files of local dir which are missing in filer dir, differ in size, or were modified locally " +
+			"after their filer copy. Nothing is deleted.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			localDir, remoteDir := args[0], args[1]
+			if !isFilerPath(remoteDir) {
+				return errors.New("filer dir must start with /: " + remoteDir)
+			}
+
+			files := make(map[string]string) // remote path -> local path
+			local := make(map[string]os.FileInfo)
+			err := filepath.Walk(localDir, func(p string, fi os.FileInfo, err error) error {
+				if err != nil || !fi.Mode().IsRegular() {
+					return err
+				}
+				rel, err := filepath.Rel(localDir, p)
+				if err == nil {
+					remote := path.Join(remoteDir, filepath.ToSlash(rel))
+					files[remote], local[remote] = p, fi
+				}
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			var opts []goseaweedfs.Option
+			if dryRun {
+				opts = append(opts, goseaweedfs.WithDryRun(nil))
+			}
+			filer, err := cfg.newFiler(opts...)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = filer.Close() }()
+
+			remotes := make([]string, 0, len(files))
+			for remote := range files {
+				remotes = append(remotes, remote)
+			}
+			sort.Strings(remotes)
+			stats := filer.StatMany(remotes, 0)
+
+			out := cmd.OutOrStdout()
+			var uploaded, skipped int
+			for _, remote := range remotes {
+				stat := stats[remote]
+				if stat.Err != nil && !errors.Is(stat.Err, goseaweedfs.ErrFileNotFound) {
+					return stat.Err
+				}
+				if stat.Info != nil && !changed(local[remote], stat.Info) {
+					skipped++
+					continue
+				}
+
+				uploaded++
+				printf(out, "upload %s -> %s\n", files[remote], remote)
+				if _, err = filer.UploadFile(files[remote], remote, cfg.collection, cfg.ttl); err != nil {
+					return err
+				}
+			}
+
+			printf(out, "%d uploaded, %d up to date\n", uploaded, skipped)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print files which would be uploaded")
+	return cmd
+}
+
+// changed reports whether local file differs from its filer copy.
+func changed(local os.FileInfo, remote *goseaweedfs.FileInfo) bool {
+	return local.Size() != remote.Size() || local.ModTime().After(remote.ModTime())
+}
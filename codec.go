@@ -0,0 +1,75 @@
+package goseaweedfs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Codec decodes responses of a content type, e.g. msgpack or protobuf. See WithCodecs.
+type Codec interface {
+	// ContentType is the media type which is negotiated with server, e.g. "application/x-msgpack".
+	ContentType() string
+	// Decode v from r.
+	Decode(r io.Reader, v interface{}) error
+}
+
+// JSONCodec is the default codec, which is used whenever server does not respond with a negotiated content type.
+var JSONCodec Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return "application/json" }
+
+func (jsonCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// accept builds Accept header from configured codecs in preference order, JSON being the last resort.
+func accept(codecs []Codec) string {
+	types := make([]string, 0, len(codecs)+1)
+	for _, codec := range codecs {
+		types = append(types, codec.ContentType())
+	}
+	return strings.Join(append(types, JSONCodec.ContentType()+";q=0.1"), ", ")
+}
+
+// codecFor returns codec of response content type, falling back to JSON.
+func (c *httpClient) codecFor(contentType string) Codec {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		for _, codec := range c.opts.codecs {
+			if strings.EqualFold(codec.ContentType(), mediaType) {
+				return codec
+			}
+		}
+	}
+	return JSONCodec
+}
+
+// getNegotiated gets url, negotiating response encoding with configured codecs. Used by high QPS master
+// requests (lookup, assign), falling back to plain JSON if no codec is configured.
+func (c *httpClient) getNegotiated(ctx context.Context, url string, out interface{}) (statusCode int, err error) {
+	if len(c.opts.codecs) == 0 {
+		return c.getJSONContext(ctx, url, nil, out)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err == nil {
+		req.Header.Set("Accept", accept(c.opts.codecs))
+
+		var resp *http.Response
+		if resp, err = c.do(req); err == nil {
+			statusCode = resp.StatusCode
+			if err = c.codecFor(resp.Header.Get("Content-Type")).Decode(newSizeGuard(resp.Body, c.opts.maxResponseSize), out); err == nil {
+				drainAndClose(resp.Body)
+			} else {
+				_ = resp.Body.Close()
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,52 @@
+package goseaweedfs
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type gobCodec struct{}
+
+func (gobCodec) ContentType() string { return "application/x-gob" }
+
+func (gobCodec) Decode(r io.Reader, v interface{}) error {
+	return gob.NewDecoder(r).Decode(v)
+}
+
+func TestAccept(t *testing.T) {
+	require.Equal(t, "application/json;q=0.1", accept(nil))
+	require.Equal(t, "application/x-gob, application/json;q=0.1", accept([]Codec{gobCodec{}}))
+}
+
+func TestCodecNegotiation(t *testing.T) {
+	assign := &AssignResult{FileID: "3,01637037d6", URL: "127.0.0.1:8080", PublicURL: "127.0.0.1:8080", Count: 1}
+
+	for _, serverSupported := range []bool{true, false} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if serverSupported && strings.Contains(r.Header.Get("Accept"), "application/x-gob") {
+				w.Header().Set("Content-Type", "application/x-gob")
+				require.Nil(t, gob.NewEncoder(w).Encode(assign))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"fid":"3,01637037d6","url":"127.0.0.1:8080","publicUrl":"127.0.0.1:8080","count":1}`)
+		}))
+
+		sw, err := NewSeaweed(server.URL, nil, 1024, server.Client(), WithCodecs(gobCodec{}))
+		require.Nil(t, err)
+
+		result, err := sw.Assign(nil)
+		require.Nil(t, err)
+		require.Equal(t, assign, result)
+
+		_ = sw.Close()
+		server.Close()
+	}
+}
@@ -0,0 +1,127 @@
+package goseaweedfs
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeCluster serves master, volume and filer APIs from one server, storing content in memory.
+func newFakeCluster(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	var seq int64
+	blobs := make(map[string][]byte)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr := server.Listener.Addr().String()
+
+		switch {
+		case r.URL.Path == "/dir/assign":
+			fmt.Fprintf(w, `{"fid":"3,%x","url":%q,"publicUrl":%q,"count":1}`, atomic.AddInt64(&seq, 1), addr, addr)
+		case r.URL.Path == "/dir/lookup":
+			fmt.Fprintf(w, `{"locations":[{"url":%q,"publicUrl":%q,"dataCenter":"dc1"}]}`, addr, addr)
+		case r.URL.Path == "/cluster/status":
+			fmt.Fprintf(w, `{"IsLeader":true,"Leader":%q}`, addr)
+		case r.URL.Path == "/dir/status":
+			fmt.Fprint(w, `{"Topology":{"Free":1,"Max":2}}`)
+		case r.URL.Query().Get("metadata") == "true":
+			fmt.Fprintf(w, `{"FullPath":%q,"FileSize":1}`, r.URL.Path)
+
+		case r.Method == http.MethodPost:
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data, _ := ioutil.ReadAll(f)
+			mu.Lock()
+			blobs[r.URL.Path] = data
+			mu.Unlock()
+			w.Header().Set("ETag", fmt.Sprintf(`"%x"`, len(data)))
+			fmt.Fprintf(w, `{"name":"f","size":%d}`, len(data))
+
+		case r.Method == http.MethodGet:
+			mu.Lock()
+			data, ok := blobs[r.URL.Path]
+			mu.Unlock()
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+// TestConcurrentUse stresses a shared client with every optional feature enabled. Run with -race.
+func TestConcurrentUse(t *testing.T) {
+	server := newFakeCluster(t)
+	defer server.Close()
+
+	resolver := func(ctx context.Context) ([]string, error) { return []string{server.URL}, nil }
+	sw, err := NewSeaweed(server.URL, nil, 1024, server.Client(),
+		WithCircuitBreaker(100, time.Second),
+		WithHedging(0.9, time.Millisecond),
+		WithLeastLoaded(),
+		WithLocality("dc1", ""),
+		WithMasterResolver(resolver),
+		WithFilerResolver(resolver),
+		WithResolveInterval(time.Millisecond),
+	)
+	require.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				content := fmt.Sprintf("content %d-%d", i, j)
+				fp, err := sw.Upload(strings.NewReader(content), "f.txt", int64(len(content)), "", "")
+				require.Nil(t, err)
+
+				r, err := sw.Fetch(fp.FileID, nil, nil)
+				require.Nil(t, err)
+				data, _ := ioutil.ReadAll(r.Body)
+				_ = r.Close()
+				require.Equal(t, content, string(data))
+
+				_, err = sw.Ping(context.Background())
+				require.Nil(t, err)
+
+				filers := sw.Filers()
+				require.Len(t, filers, 1)
+				results := filers[0].StatMany([]string{"/a", "/b"}, 2)
+				require.Nil(t, results["/a"].Err)
+
+				_ = sw.BreakerStates()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// close is idempotent, also when racing
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.Nil(t, sw.Close())
+		}()
+	}
+	wg.Wait()
+}
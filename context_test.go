@@ -0,0 +1,69 @@
+package goseaweedfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestUploadContext(t *testing.T) {
+	// server does not notice aborted requests until it reads body, so stuck handlers are released explicitly
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stuck" {
+			<-release
+			return
+		}
+		fmt.Fprint(w, `{"name":"a","size":7}`)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	// more aborted uploads than workers: leaked streaming tasks would starve the pool
+	for i := 0; i <= runtime.NumCPU()<<1; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err = filer.Upload(zeroReader{}, 0, "/stuck", "", "", WithContext(ctx))
+		cancel()
+		require.True(t, errors.Is(err, context.DeadlineExceeded), "%v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := filer.Upload(strings.NewReader("content"), 7, "/a.txt", "", "")
+		done <- err
+	}()
+
+	select {
+	case err = <-done:
+		require.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("upload is blocked by aborted ones")
+	}
+
+	// canceled before start
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = filer.Upload(strings.NewReader("content"), 7, "/a.txt", "", "", WithContext(ctx))
+	require.True(t, errors.Is(err, context.Canceled))
+}
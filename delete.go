@@ -0,0 +1,114 @@
+package goseaweedfs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// DeleteResult result of deleting a file by id or a filer entry.
+type DeleteResult struct {
+	// Target file id or filer path.
+	Target string
+
+	// Found reports whether target existed.
+	Found bool
+
+	// Chunks number of chunks removed by cascading, see WithCascadeChunks.
+	Chunks int
+
+	// BlobsPurged reports whether volume blobs were removed along with filer entry, see WithKeepChunks.
+	BlobsPurged bool
+}
+
+// DeleteFileWithOptions deletes file by id, customized by options.
+func (c *Seaweed) DeleteFileWithOptions(fileID string, args url.Values, opts ...DeleteOption) (result *DeleteResult, err error) {
+	o := newDeleteOptions(opts)
+	result = &DeleteResult{Target: fileID, BlobsPurged: true}
+
+	var cm *ChunkManifest
+	if o.cascadeChunks {
+		if cm, err = c.loadChunkManifest(fileID, args); err != nil && !errors.Is(err, ErrFileNotFound) {
+			return nil, err
+		}
+	}
+
+	fileURL, err := c.LookupFileID(fileID, args, false)
+	if err != nil {
+		return nil, err
+	}
+
+	statusCode, err := c.client.delete(fileURL)
+	if err != nil {
+		return nil, err
+	}
+	result.Found = statusCode != http.StatusNotFound
+	if !result.Found && o.strictNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, fileID)
+	}
+
+	// manifest is removed first, so a failure leaves orphan chunks rather than a broken file
+	if cm != nil {
+		if err = c.DeleteChunks(cm, args); err != nil {
+			return nil, err
+		}
+		result.Chunks = len(cm.Chunks)
+	}
+	return
+}
+
+// loadChunkManifest returns manifest of a chunk manifested file, nil if file is not chunked. Volume server marks
+// chunked files by X-File-Store header only when serving them assembled, so file is probed by HEAD first, then
+// raw manifest is read with cm=false.
+func (c *Seaweed) loadChunkManifest(fileID string, args url.Values) (cm *ChunkManifest, err error) {
+	server, err := c.LookupServerByFileID(fileID, args, true)
+	if err != nil {
+		return
+	}
+
+	base := c.masterURL()
+	base.Host = server
+
+	r, err := c.client.fetch(http.MethodHead, encodeURI(base, fileID, nil), nil)
+	if err != nil || r.Header.Get("X-File-Store") != "chunked" {
+		return
+	}
+
+	if r, err = c.client.fetch(http.MethodGet, encodeURI(base, fileID, url.Values{"cm": []string{"false"}}), nil); err != nil {
+		return
+	}
+	defer func() { _ = r.Close() }()
+
+	cm = &ChunkManifest{}
+	if err = json.NewDecoder(newSizeGuard(r.Body, c.opts.maxResponseSize)).Decode(cm); err != nil {
+		cm = nil
+	}
+	return
+}
+
+// DeleteWithOptions deletes a file/dir, customized by options.
+func (f *Filer) DeleteWithOptions(path string, opts ...DeleteOption) (result *DeleteResult, err error) {
+	o := newDeleteOptions(opts)
+	result = &DeleteResult{Target: path, BlobsPurged: !o.keepChunks}
+
+	args := url.Values{}
+	if o.recursive {
+		args.Set("recursive", "true")
+	}
+	if o.keepChunks {
+		args.Set("skipChunkDeletion", "true")
+	}
+
+	statusCode, err := f.client.delete(encodeURI(*f.base, path, args))
+	if err != nil {
+		return nil, err
+	}
+
+	result.Found = statusCode != http.StatusNotFound
+	if !result.Found && o.strictNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, path)
+	}
+	return
+}
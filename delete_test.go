@@ -0,0 +1,91 @@
+package goseaweedfs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteWithOptions(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+	files := map[string]bool{"/3,01": true, "/3,02": true, "/3,03": true, "/3,04": true, "/dir": true}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/dir/lookup":
+			addr := server.Listener.Addr().String()
+			fmt.Fprintf(w, `{"locations":[{"url":%q,"publicUrl":%q}]}`, addr, addr)
+
+		// like volume server, raw manifest is served with cm=false, while assembled content is marked as chunked
+		case r.Method == http.MethodGet && r.URL.Path == "/3,01" && r.URL.Query().Get("cm") == "false":
+			fmt.Fprint(w, `{"name":"big","size":16,"chunks":[{"fid":"3,02","size":8},{"fid":"3,03","offset":8,"size":8}]}`)
+
+		case (r.Method == http.MethodGet || r.Method == http.MethodHead) && files[r.URL.Path]:
+			if r.URL.Path == "/3,01" {
+				w.Header().Set("X-File-Store", "chunked")
+			}
+			w.Header().Set("Content-Length", "16")
+
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.RequestURI())
+			if !files[r.URL.Path] {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(files, r.URL.Path)
+			w.WriteHeader(http.StatusAccepted)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	sw, err := NewSeaweed(server.URL, []string{server.URL}, 1024, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	result, err := sw.DeleteFileWithOptions("3,01", nil, WithCascadeChunks())
+	require.Nil(t, err)
+	require.Equal(t, &DeleteResult{Target: "3,01", Found: true, Chunks: 2, BlobsPurged: true}, result)
+	sort.Strings(deleted[1:])
+	require.Equal(t, []string{"/3,01", "/3,02", "/3,03"}, deleted)
+
+	result, err = sw.DeleteFileWithOptions("3,01", nil)
+	require.Nil(t, err)
+	require.False(t, result.Found)
+
+	// plain file has no chunks to cascade
+	deleted = nil
+	result, err = sw.DeleteFileWithOptions("3,04", nil, WithCascadeChunks())
+	require.Nil(t, err)
+	require.Equal(t, 0, result.Chunks)
+	require.Equal(t, []string{"/3,04"}, deleted)
+
+	_, err = sw.DeleteFileWithOptions("3,01", nil, WithStrictNotFound())
+	require.True(t, errors.Is(err, ErrFileNotFound))
+
+	deleted = nil
+	filer := sw.Filers()[0]
+	result, err = filer.DeleteWithOptions("/dir", WithRecursive(), WithKeepChunks())
+	require.Nil(t, err)
+	require.Equal(t, &DeleteResult{Target: "/dir", Found: true}, result)
+	require.Len(t, deleted, 1)
+	u, _ := url.Parse(deleted[0])
+	require.Equal(t, url.Values{"recursive": {"true"}, "skipChunkDeletion": {"true"}}, u.Query())
+
+	_, err = filer.DeleteWithOptions("/dir", WithStrictNotFound())
+	require.True(t, errors.Is(err, ErrFileNotFound))
+}
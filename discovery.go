@@ -0,0 +1,144 @@
+package goseaweedfs
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultResolveInterval is the default interval of re-resolving master/filer endpoints.
+const DefaultResolveInterval = 30 * time.Second
+
+// Resolver discovers endpoint urls of masters/filers, e.g. from DNS or a service registry like Consul.
+type Resolver func(ctx context.Context) ([]string, error)
+
+// SRVResolver resolves endpoints from DNS SRV records of _service._proto.name, ordered by priority and weight.
+// Targets are returned as http urls. Empty service and proto look up name directly.
+func SRVResolver(service, proto, name string) Resolver {
+	return func(ctx context.Context) ([]string, error) {
+		_, records, err := net.DefaultResolver.LookupSRV(ctx, service, proto, name)
+		if err != nil {
+			return nil, err
+		}
+
+		sort.SliceStable(records, func(i, j int) bool {
+			if records[i].Priority != records[j].Priority {
+				return records[i].Priority < records[j].Priority
+			}
+			return records[i].Weight > records[j].Weight
+		})
+
+		urls := make([]string, 0, len(records))
+		for _, r := range records {
+			host := strings.TrimSuffix(r.Target, ".")
+			urls = append(urls, "http://"+net.JoinHostPort(host, strconv.Itoa(int(r.Port))))
+		}
+		return urls, nil
+	}
+}
+
+// HostResolver resolves all addresses of host name (e.g. a headless Kubernetes service) into http urls with given port.
+func HostResolver(host string, port int) Resolver {
+	return func(ctx context.Context) ([]string, error) {
+		addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(addrs)
+		urls := make([]string, 0, len(addrs))
+		for _, addr := range addrs {
+			urls = append(urls, "http://"+net.JoinHostPort(addr, strconv.Itoa(port)))
+		}
+		return urls, nil
+	}
+}
+
+// errNoEndpoint return when resolver found nothing.
+var errNoEndpoint = errors.New("Resolver returned no endpoint")
+
+// startDiscovery resolves endpoints once, then keeps re-resolving them in background.
+func (c *Seaweed) startDiscovery() (err error) {
+	o := c.opts
+	if o.masterResolver == nil && o.filerResolver == nil {
+		return
+	}
+
+	// initial resolving must succeed unless there is a configured fallback
+	if e := c.resolveMaster(); e != nil && c.master.Load() == nil {
+		return e
+	}
+	_ = c.resolveFilers()
+
+	c.stopDiscovery = make(chan struct{})
+	go c.discover(c.stopDiscovery, o.resolveInterval)
+	return
+}
+
+func (c *Seaweed) discover(stop chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			_ = c.resolveMaster()
+			_ = c.resolveFilers()
+		}
+	}
+}
+
+func (c *Seaweed) resolve(r Resolver) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.resolveInterval)
+	defer cancel()
+
+	urls, err := r(ctx)
+	if err == nil && len(urls) == 0 {
+		err = errNoEndpoint
+	}
+	return urls, err
+}
+
+func (c *Seaweed) resolveMaster() error {
+	if c.opts.masterResolver == nil {
+		return nil
+	}
+
+	urls, err := c.resolve(c.opts.masterResolver)
+	if err != nil {
+		return err
+	}
+
+	// keep current master while it is still resolved, any master proxies requests to leader
+	current := c.masterURL()
+	for _, u := range urls {
+		if parsed, e := parseURI(u); e == nil && parsed.Host == current.Host {
+			return nil
+		}
+	}
+
+	var u *url.URL
+	if u, err = parseURI(urls[0]); err == nil {
+		c.master.Store(u)
+	}
+	return err
+}
+
+func (c *Seaweed) resolveFilers() error {
+	if c.opts.filerResolver == nil {
+		return nil
+	}
+
+	urls, err := c.resolve(c.opts.filerResolver)
+	if err == nil {
+		err = c.setFilers(urls)
+	}
+	return err
+}
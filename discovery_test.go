@@ -0,0 +1,72 @@
+package goseaweedfs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResolver struct {
+	sync.Mutex
+	urls []string
+	err  error
+}
+
+func (f *fakeResolver) set(err error, urls ...string) {
+	f.Lock()
+	f.urls, f.err = urls, err
+	f.Unlock()
+}
+
+func (f *fakeResolver) resolve(ctx context.Context) ([]string, error) {
+	f.Lock()
+	defer f.Unlock()
+	return f.urls, f.err
+}
+
+func TestDiscovery(t *testing.T) {
+	masters, filers := &fakeResolver{}, &fakeResolver{}
+
+	masters.set(errors.New("dns failure"))
+	_, err := NewSeaweed("", nil, 1024, nil, WithMasterResolver(masters.resolve))
+	require.NotNil(t, err)
+
+	// fallback to configured master
+	sw, err := NewSeaweed("http://fallback:9333", nil, 1024, nil, WithMasterResolver(masters.resolve))
+	require.Nil(t, err)
+	require.Equal(t, "fallback:9333", sw.masterURL().Host)
+	require.Nil(t, sw.Close())
+
+	masters.set(nil, "http://m1:9333", "http://m2:9333")
+	filers.set(nil, "http://f1:8888")
+	sw, err = NewSeaweed("", nil, 1024, nil,
+		WithMasterResolver(masters.resolve),
+		WithFilerResolver(filers.resolve),
+		WithResolveInterval(10*time.Millisecond),
+	)
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	require.Equal(t, "m1:9333", sw.masterURL().Host)
+	require.Len(t, sw.Filers(), 1)
+	f1 := sw.Filers()[0]
+
+	// current master is kept while still resolved
+	masters.set(nil, "http://m2:9333", "http://m1:9333")
+	filers.set(nil, "http://f2:8888", "http://f1:8888")
+	require.Eventually(t, func() bool { return len(sw.Filers()) == 2 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, "m1:9333", sw.masterURL().Host)
+	require.Same(t, f1, sw.Filers()[1])
+
+	// failover to another master, failure keeps the last known endpoints
+	masters.set(nil, "http://m3:9333")
+	require.Eventually(t, func() bool { return sw.masterURL().Host == "m3:9333" }, time.Second, 5*time.Millisecond)
+
+	filers.set(errors.New("dns failure"))
+	time.Sleep(50 * time.Millisecond)
+	require.Len(t, sw.Filers(), 2)
+}
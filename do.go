@@ -0,0 +1,89 @@
+package goseaweedfs
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Do sends an arbitrary request, e.g. to an endpoint not covered by this package, with client-wide policies
+// applied as to any other request: identity headers, circuit breaker, load tracking, dry run (requests other
+// than GET/HEAD, having unknown effect, are skipped and answered with an empty 200 response), redirect policy
+// of GET/HEAD requests and retries (see WithUploadRetry) of idempotent requests whose body can be re-sent
+// (see http.Request.GetBody). Relative request url is resolved against master. Request is not modified.
+// Caller must close response body.
+func (c *Seaweed) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return c.client.doRaw(ctx, c.masterURL(), req)
+}
+
+// Do sends an arbitrary request, see Seaweed.Do. Relative request url is resolved against filer.
+func (f *Filer) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return f.client.doRaw(ctx, *f.base, req)
+}
+
+func (c *httpClient) doRaw(ctx context.Context, base url.URL, req *http.Request) (resp *http.Response, err error) {
+	req = req.Clone(ctx)
+	if !req.URL.IsAbs() {
+		req.URL, req.Host = base.ResolveReference(req.URL), ""
+	}
+
+	send := c.do
+	if req.Method == "" || req.Method == http.MethodGet || req.Method == http.MethodHead {
+		send = c.read
+	} else if c.dryRun(req.Method, req.URL.String()) {
+		return &http.Response{
+			Status:     "200 OK",
+			StatusCode: http.StatusOK,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+
+	retries := 0
+	if idempotent(req) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil) {
+		retries = c.opts.uploadRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err = send(req)
+
+		statusCode := 0
+		if err == nil {
+			statusCode = resp.StatusCode
+		}
+		if attempt >= retries || !retriable(statusCode, err) {
+			return
+		}
+		if err == nil {
+			drainAndClose(resp.Body)
+		}
+
+		select {
+		case <-time.After(backoff(c.opts.retryBackoff, attempt)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if req.GetBody != nil {
+			next := req.Clone(ctx)
+			if next.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+			req = next
+		}
+	}
+}
+
+// idempotent reports whether request could be sent twice without side effects, by method or idempotency key.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
@@ -0,0 +1,89 @@
+package goseaweedfs
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDo(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		mu.Lock()
+		key := r.Method + " " + r.URL.Path
+		calls[key]++
+		n := calls[key]
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+
+		require.Equal(t, "tester", r.Header.Get("User-Agent"))
+		if strings.HasPrefix(r.URL.Path, "/flaky") && n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(r.URL.RawQuery))
+	}))
+	defer server.Close()
+
+	sw, err := NewSeaweed(server.URL, []string{server.URL}, 1024, server.Client(),
+		WithUserAgent("tester"), WithUploadRetry(3, time.Millisecond))
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	// relative url resolved against master, retried until succeeded
+	req, err := http.NewRequest(http.MethodGet, "/flaky/raft?pretty=y", nil)
+	require.Nil(t, err)
+	resp, err := sw.Do(context.Background(), req)
+	require.Nil(t, err)
+	data, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "pretty=y", string(data))
+	require.Equal(t, 3, calls["GET /flaky/raft"])
+	require.Empty(t, req.URL.Host)
+
+	// body is re-sent
+	req, err = http.NewRequest(http.MethodPut, "/flaky/put", strings.NewReader("content"))
+	require.Nil(t, err)
+	resp, err = sw.Filers()[0].Do(context.Background(), req)
+	require.Nil(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, []string{"", "", "", "content", "content", "content"}, bodies)
+
+	// non-idempotent request is sent once
+	req, err = http.NewRequest(http.MethodPost, server.URL+"/flaky/post", nil)
+	require.Nil(t, err)
+	resp, err = sw.Do(context.Background(), req)
+	require.Nil(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, 1, calls["POST /flaky/post"])
+
+	// dry run
+	var skipped []string
+	dry, err := NewFiler(server.URL, server.Client(), WithUserAgent("tester"), WithDryRun(func(method, url string) {
+		skipped = append(skipped, method+" "+url)
+	}))
+	require.Nil(t, err)
+	defer func() { _ = dry.Close() }()
+
+	req, err = http.NewRequest(http.MethodDelete, "/a.txt", nil)
+	require.Nil(t, err)
+	resp, err = dry.Do(context.Background(), req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, []string{"DELETE " + server.URL + "/a.txt"}, skipped)
+	require.Zero(t, calls["DELETE /a.txt"])
+}
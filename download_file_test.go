@@ -0,0 +1,64 @@
+package goseaweedfs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadToFileFast(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 200000) // bigger than copy buffer
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/large.bin":
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			_, _ = w.Write(content)
+		case "/unknown-size.bin":
+			_, _ = w.Write(content[:10])
+			w.(http.Flusher).Flush()
+			_, _ = w.Write(content[10:20])
+		case "/truncated.bin":
+			w.Header().Set("Content-Length", "100")
+			_, _ = w.Write(content[:10])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "large.bin")
+	n, err := filer.DownloadToFileFast("/large.bin", local)
+	require.Nil(t, err)
+	require.EqualValues(t, len(content), n)
+	data, _ := ioutil.ReadFile(local)
+	require.Equal(t, content, data)
+
+	// overwrites existing longer file
+	n, err = filer.DownloadToFileFast("/unknown-size.bin", local)
+	require.Nil(t, err)
+	require.EqualValues(t, 20, n)
+	data, _ = ioutil.ReadFile(local)
+	require.Equal(t, content[:20], data)
+
+	// partial file is removed
+	_, err = filer.DownloadToFileFast("/truncated.bin", local)
+	require.NotNil(t, err)
+	_, err = os.Stat(local)
+	require.True(t, os.IsNotExist(err))
+
+	_, err = filer.DownloadToFileFast("/missing.bin", filepath.Join(dir, "missing.bin"))
+	require.NotNil(t, err)
+}
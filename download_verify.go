@@ -0,0 +1,37 @@
+package goseaweedfs
+
+import "fmt"
+
+// ErrTruncatedBody returned when a downloaded body ended before its declared Content-Length, see WithDownloadVerification.
+var ErrTruncatedBody = fmt.Errorf("Truncated response body")
+
+// WithDownloadVerification makes callback based downloads verify that the whole body was received once callback
+// completed: reading must end by EOF, after Content-Length bytes if present. Otherwise download fails with
+// ErrTruncatedBody, even if callback swallowed the read error. Remainder left unread by callback is drained and
+// counted as received.
+func WithDownloadVerification() Option {
+	return func(o *options) {
+		o.verifyDownloads = true
+	}
+}
+
+// finishDownload drains and closes body once callback returned err, verifying it if enabled.
+func (c *httpClient) finishDownload(result *DownloadResult, err error) error {
+	drainAndClose(result.Body)
+
+	if b, ok := result.Body.(*downloadBody); ok && err == nil && c.opts.verifyDownloads {
+		err = b.verify()
+	}
+	return err
+}
+
+// verify checks whole body was read.
+func (b *downloadBody) verify() error {
+	if b.err != nil {
+		return fmt.Errorf("%w: %v", ErrTruncatedBody, b.err)
+	}
+	if b.expected >= 0 && b.read != b.expected {
+		return fmt.Errorf("%w: received %d of %d bytes", ErrTruncatedBody, b.read, b.expected)
+	}
+	return nil
+}
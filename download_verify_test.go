@@ -0,0 +1,72 @@
+package goseaweedfs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadVerification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/full.txt" {
+			fmt.Fprint(w, "0123456789")
+			return
+		}
+
+		// connection closed after 10 of declared 100 bytes
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		require.Nil(t, err)
+		defer func() { _ = conn.Close() }()
+		fmt.Fprint(rw, "HTTP/1.1 200 OK\r\nContent-Length: 100\r\nContent-Type: text/plain\r\n\r\n0123456789")
+		_ = rw.Flush()
+	}))
+	defer server.Close()
+
+	// callback swallowing read error
+	lenient := func(r io.Reader) error {
+		_, _ = ioutil.ReadAll(r)
+		return nil
+	}
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	require.Nil(t, filer.Download("/truncated.txt", nil, lenient))
+	_ = filer.Close()
+
+	filer, err = NewFiler(server.URL, server.Client(), WithDownloadVerification())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	err = filer.Download("/truncated.txt", nil, lenient)
+	require.True(t, errors.Is(err, ErrTruncatedBody), "%v", err)
+
+	require.Nil(t, filer.Download("/full.txt", nil, lenient))
+
+	// remainder not read by callback is drained and verified
+	require.Nil(t, filer.Download("/full.txt", nil, func(r io.Reader) error {
+		_, err := bufio.NewReader(r).ReadByte()
+		return err
+	}))
+
+	// callback error wins
+	failure := fmt.Errorf("failure")
+	err = filer.Download("/truncated.txt", nil, func(io.Reader) error { return failure })
+	require.Equal(t, failure, err)
+}
+
+func TestDownloadBodyVerify(t *testing.T) {
+	body := &downloadBody{ReadCloser: ioutil.NopCloser(io.LimitReader(zeroReader{}, 10)), expected: 12}
+	_, _ = ioutil.ReadAll(body)
+	require.True(t, errors.Is(body.verify(), ErrTruncatedBody))
+
+	body = &downloadBody{ReadCloser: ioutil.NopCloser(io.LimitReader(zeroReader{}, 10)), expected: -1}
+	_, _ = ioutil.ReadAll(body)
+	require.Nil(t, body.verify())
+}
@@ -27,8 +27,15 @@ type FilePart struct {
 	// 8y: 8 years
 	TTL string
 
+	// Replication type of file, e.g. "001". Default to master's configuration if empty.
+	Replication string
+
 	Server string
 	FileID string
+
+	// Result of uploading file part, set once uploaded by Seaweed. For chunked files, it is the result
+	// of uploading chunk manifest, with Size of the whole file.
+	Result *UploadResult
 }
 
 // Close underlying openned file.
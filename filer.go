@@ -1,17 +1,31 @@
 package goseaweedfs
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 )
 
-// Filer client
+// idempotencyKeyHeader stores idempotency key of uploaded entry, see WithIdempotencyKey.
+const idempotencyKeyHeader = "Seaweed-Idempotency-Key"
+
+// paramSaveInside makes filer store content inline in entry metadata.
+const paramSaveInside = "saveInside"
+
+// Filer client. Filer is safe for concurrent use by multiple goroutines.
 type Filer struct {
 	base   *url.URL
 	client *httpClient
+
+	capabilities capabilityCache
 }
 
 // FilerUploadResult upload result which responsed from filer server. According to https://github.com/chrislusf/seaweedfs/wiki/Filer-Server-API.
@@ -20,12 +34,103 @@ type FilerUploadResult struct {
 	FileURL string `json:"url,omitempty"`
 	FileID  string `json:"fid,omitempty"`
 	Size    int64  `json:"size,omitempty"`
+	ETag    string `json:"eTag,omitempty"`
 	Error   string `json:"error,omitempty"`
+
+	// Header raw response header.
+	Header http.Header `json:"-"`
+}
+
+func (r *FilerUploadResult) setHeader(header http.Header) {
+	r.Header = header
+	if r.ETag == "" {
+		r.ETag = strings.Trim(header.Get("ETag"), "\"")
+	}
+}
+
+// FileChunk chunk of a filer entry.
+type FileChunk struct {
+	FileID string `json:"file_id,omitempty"`
+	Offset int64  `json:"offset,omitempty"`
+	Size   uint64 `json:"size,omitempty"`
+	Mtime  int64  `json:"mtime,omitempty"`
+	ETag   string `json:"e_tag,omitempty"`
+}
+
+// FileInfo entry metadata responsed from filer server with `metadata=true` param. Implements os.FileInfo.
+type FileInfo struct {
+	FullPath    string            `json:"FullPath"`
+	Mtime       time.Time         `json:"Mtime"`
+	Crtime      time.Time         `json:"Crtime"`
+	FileMode    os.FileMode       `json:"Mode"`
+	Mime        string            `json:"Mime"`
+	Replication string            `json:"Replication"`
+	Collection  string            `json:"Collection"`
+	TTLSec      int32             `json:"TtlSec"`
+	FileSize    uint64            `json:"FileSize"`
+	Md5         []byte            `json:"Md5"`
+	Extended    map[string][]byte `json:"Extended"`
+	Chunks      []*FileChunk      `json:"chunks,omitempty"`
+
+	// Quota in bytes configured on directory (e.g. a bucket), 0 means unlimited.
+	Quota int64 `json:"Quota,omitempty"`
+
+	// Content of small file stored inline in metadata, see WithSaveInside.
+	Content []byte `json:"Content,omitempty"`
+}
+
+// Name base name of entry.
+func (fi *FileInfo) Name() string {
+	return path.Base(fi.FullPath)
+}
+
+// Size of file. Older filer servers do not report file size, so it is calculated from chunks.
+func (fi *FileInfo) Size() int64 {
+	if fi.FileSize > 0 {
+		return int64(fi.FileSize)
+	}
+
+	var size int64
+	for _, c := range fi.Chunks {
+		if end := c.Offset + int64(c.Size); end > size {
+			size = end
+		}
+	}
+	return size
+}
+
+// Mode file mode bits.
+func (fi *FileInfo) Mode() os.FileMode {
+	return fi.FileMode
+}
+
+// ModTime modification time.
+func (fi *FileInfo) ModTime() time.Time {
+	return fi.Mtime
+}
+
+// IsDir reports whether entry is a directory.
+func (fi *FileInfo) IsDir() bool {
+	return fi.FileMode.IsDir()
+}
+
+// Sys returns nil.
+func (fi *FileInfo) Sys() interface{} {
+	return nil
+}
+
+// FilerListing a page of directory listing responsed from filer server.
+type FilerListing struct {
+	Path                  string      `json:"Path"`
+	Entries               []*FileInfo `json:"Entries"`
+	Limit                 int         `json:"Limit"`
+	LastFileName          string      `json:"LastFileName"`
+	ShouldDisplayLoadMore bool        `json:"ShouldDisplayLoadMore"`
 }
 
 // NewFiler new filer with filer server's url
-func NewFiler(u string, client *http.Client) (f *Filer, err error) {
-	return newFiler(u, newHTTPClient(client))
+func NewFiler(u string, client *http.Client, opts ...Option) (f *Filer, err error) {
+	return newFiler(u, newHTTPClient(client, newOptions(opts)))
 }
 
 func newFiler(u string, client *httpClient) (f *Filer, err error) {
@@ -50,35 +155,103 @@ func (f *Filer) Close() (err error) {
 	return
 }
 
+// BreakerStates returns circuit breaker state per host. Returns nil if circuit breaker is not enabled.
+func (f *Filer) BreakerStates() map[string]BreakerState {
+	return f.client.breakerStates()
+}
+
 // UploadFile a file.
-func (f *Filer) UploadFile(localFilePath, newPath, collection, ttl string) (result *FilerUploadResult, err error) {
+func (f *Filer) UploadFile(localFilePath, newPath, collection, ttl string, opts ...UploadOption) (result *FilerUploadResult, err error) {
 	fp, err := NewFilePart(localFilePath)
 	if err == nil {
-		var data []byte
-		data, _, err = f.client.upload(encodeURI(*f.base, newPath, normalize(nil, collection, ttl)), localFilePath, fp.Reader, fp.MimeType, nil)
-		if err == nil {
-			result = &FilerUploadResult{}
-			err = json.Unmarshal(data, result)
-		}
-
+		fp.Collection, fp.TTL = collection, ttl
+		result, err = f.UploadFilePart(fp, newPath, opts...)
 		_ = fp.Close()
 	}
 	return
 }
 
 // Upload content.
-func (f *Filer) Upload(content io.Reader, fileSize int64, newPath, collection, ttl string) (result *FilerUploadResult, err error) {
-	fp := NewFilePartFromReader(ioutil.NopCloser(content), newPath, fileSize)
+func (f *Filer) Upload(content io.Reader, fileSize int64, newPath, collection, ttl string, opts ...UploadOption) (result *FilerUploadResult, err error) {
+	fp := NewFilePartFromReader(nopCloser(content), newPath, fileSize)
+	fp.Collection, fp.TTL = collection, ttl
+	result, err = f.UploadFilePart(fp, newPath, opts...)
+	return
+}
 
-	var data []byte
-	data, _, err = f.client.upload(encodeURI(*f.base, newPath, normalize(nil, collection, ttl)), newPath, ioutil.NopCloser(content), "", nil)
-	if err == nil {
-		result = &FilerUploadResult{}
-		err = json.Unmarshal(data, result)
+// UploadFilePart uploads file part to newPath.
+func (f *Filer) UploadFilePart(fp *FilePart, newPath string, opts ...UploadOption) (result *FilerUploadResult, err error) {
+	o := newUploadOptions(opts)
+
+	header := make(http.Header)
+	if o.idempotencyKey != "" {
+		var done bool
+		if result, done, err = f.checkIdempotencyKey(newPath, o.idempotencyKey); err != nil || done {
+			return
+		}
+		header.Set(idempotencyKeyHeader, o.idempotencyKey)
 	}
 
-	_ = fp.Close()
+	if o.exclusive {
+		// a filer ignoring the precondition would silently overwrite, so refuse instead of sending it
+		var caps *Capabilities
+		if caps, err = f.Capabilities(o.ctx); err != nil {
+			return
+		}
+		if !caps.ConditionalCreate {
+			return nil, fmt.Errorf("%w: exclusive create needs a newer filer than %s", ErrUnsupportedByServer, caps.Version)
+		}
+		header.Set("If-None-Match", "*")
+	}
+
+	args := normalize(nil, fp.Collection, fp.TTL)
+	if fp.Replication != "" {
+		args.Set(ParamAssignReplication, fp.Replication)
+	}
+	if o.saveInside || (fp.FileSize > 0 && fp.FileSize <= f.client.opts.inlineThreshold) {
+		args.Set(paramSaveInside, "true")
+	}
 
+	quotaDone, err := f.client.reserveQuota(fp.Collection, fp.FileSize)
+	if err != nil {
+		return
+	}
+
+	result = &FilerUploadResult{}
+	defer func() {
+		var written int64
+		if result != nil {
+			written = result.Size
+		}
+		quotaDone(written, err)
+	}()
+
+	if _, err = f.client.uploadContext(o.ctx, encodeURI(*f.base, newPath, args), fp.FileName, withBodyFactory(fp.Reader, o.getBody), fp.FileSize, fp.MimeType, header, result); err != nil {
+		result = nil
+	}
+	return
+}
+
+// checkIdempotencyKey checks whether entry at path was already written with given idempotency key.
+func (f *Filer) checkIdempotencyKey(filePath, key string) (result *FilerUploadResult, done bool, err error) {
+	r, err := f.client.fetch(http.MethodHead, encodeURI(*f.base, filePath, nil), nil)
+	if err != nil {
+		if errors.Is(err, ErrFileNotFound) {
+			err = nil
+		}
+		return
+	}
+
+	if done = r.Header.Get(idempotencyKeyHeader) == key; done {
+		result = &FilerUploadResult{
+			Name: r.Name,
+			Size: r.Size,
+		}
+		if result.Name == "" {
+			result.Name = path.Base(filePath)
+		}
+		result.setHeader(r.Header)
+	}
 	return
 }
 
@@ -88,9 +261,17 @@ func (f *Filer) Get(path string, args url.Values, header map[string]string) (dat
 	return
 }
 
+// Fetch a file. Returned result contains file metadata and its body, which must be closed by caller.
+func (f *Filer) Fetch(path string, args url.Values, header http.Header) (result *DownloadResult, err error) {
+	result, err = f.client.fetch(http.MethodGet, encodeURI(*f.base, path, args), header)
+	return
+}
+
 // Download a file.
 func (f *Filer) Download(path string, args url.Values, callback func(io.Reader) error) (err error) {
-	_, err = f.client.download(encodeURI(*f.base, path, args), callback)
+	_, err = f.client.download(encodeURI(*f.base, path, args), nil, func(r *DownloadResult) error {
+		return callback(r.Body)
+	})
 	return
 }
 
@@ -99,3 +280,151 @@ func (f *Filer) Delete(path string, args url.Values) (err error) {
 	_, err = f.client.delete(encodeURI(*f.base, path, args))
 	return
 }
+
+// Stat returns entry info of a file/dir. Returns ErrFileNotFound if entry does not exist.
+func (f *Filer) Stat(path string) (fi *FileInfo, err error) {
+	return f.stat(context.Background(), path)
+}
+
+func (f *Filer) stat(ctx context.Context, path string) (fi *FileInfo, err error) {
+	u := encodeURI(*f.base, path, url.Values{"metadata": []string{"true"}})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := f.client.do(req)
+	if err != nil {
+		return
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		drainAndClose(resp.Body)
+		err = ErrFileNotFound
+	case resp.StatusCode >= http.StatusBadRequest:
+		// error bodies are small, keep them to be reported
+		body, statusCode, _ := readAll(resp, f.client.opts.maxResponseSize)
+		err = responseError("Stat", u, body, statusCode)
+	default:
+		fi = &FileInfo{}
+		if _, err = decodeJSON(resp, f.client.opts.maxResponseSize, fi); err != nil {
+			fi = nil
+		}
+	}
+	return
+}
+
+// StatResult result of stating an entry, see StatMany.
+type StatResult struct {
+	Info *FileInfo
+	Err  error
+}
+
+// DefaultStatConcurrency is the default number of concurrent requests of StatMany.
+const DefaultStatConcurrency = 8
+
+// StatMany stats many entries concurrently, returning result per path. Concurrency defaults
+// to DefaultStatConcurrency if not positive. Missing entries have ErrFileNotFound.
+func (f *Filer) StatMany(paths []string, concurrency int) map[string]*StatResult {
+	if concurrency <= 0 {
+		concurrency = DefaultStatConcurrency
+	}
+
+	results := make(map[string]*StatResult, len(paths))
+	for _, p := range paths {
+		results[p] = &StatResult{}
+	}
+
+	g := newTaskGroup(concurrency)
+	for p, r := range results {
+		p, r := p, r
+		g.Go(func() error {
+			r.Info, r.Err = f.Stat(p)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	return results
+}
+
+// SetTags replaces custom metadata of an entry in place, without rewriting content.
+// Returns ErrUnsupportedByServer if rejected by a filer too old to support tagging.
+func (f *Filer) SetTags(path string, tags map[string]string) (err error) {
+	statusCode, err := f.client.put(encodeURI(*f.base, path, url.Values{"tagging": []string{""}}), metadataHeader(tags))
+	return f.explainRejection("tagging", statusCode, err, func(c *Capabilities) bool { return c.Tagging })
+}
+
+// Exists checks if a file/dir exists, using HEAD request.
+func (f *Filer) Exists(path string) (exists bool, err error) {
+	_, err = f.client.fetch(http.MethodHead, encodeURI(*f.base, path, nil), nil)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, ErrFileNotFound) {
+		return false, nil
+	}
+	return
+}
+
+// Mkdir creates a directory. Filer creates parent directories implicitly.
+func (f *Filer) Mkdir(dir string) (err error) {
+	if !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	_, err = f.client.post(encodeURI(*f.base, dir, nil))
+	return
+}
+
+// Rename moves a file/dir to new path, server side. Returns ErrUnsupportedByServer if rejected by a filer
+// too old to move.
+func (f *Filer) Rename(oldPath, newPath string) (err error) {
+	statusCode, err := f.client.post(encodeURI(*f.base, newPath, url.Values{"mv.from": []string{oldPath}}))
+	return f.explainRejection("move", statusCode, err, func(c *Capabilities) bool { return c.Move })
+}
+
+// ListDir lists all entries of a directory, fetching page by page under the hood.
+func (f *Filer) ListDir(dir string) (entries []*FileInfo, err error) {
+	lastFileName := ""
+	for {
+		var page *FilerListing
+		if page, err = f.listDir(dir, lastFileName, 0); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, page.Entries...)
+		if !page.ShouldDisplayLoadMore || len(page.Entries) == 0 {
+			return
+		}
+		lastFileName = page.LastFileName
+	}
+}
+
+func (f *Filer) listDir(dir, lastFileName string, limit int) (page *FilerListing, err error) {
+	if t := f.client.opts.transport; t != nil {
+		return t.ListDir(context.Background(), f.base.Host, path.Clean("/"+dir), lastFileName, limit)
+	}
+
+	args := make(url.Values)
+	if lastFileName != "" {
+		args.Set("lastFileName", lastFileName)
+	}
+	if limit > 0 {
+		args.Set("limit", strconv.Itoa(limit))
+	}
+
+	if !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+
+	page = &FilerListing{}
+	statusCode, err := f.client.getJSON(encodeURI(*f.base, dir, args), map[string]string{"Accept": "application/json"}, page)
+	if statusCode == http.StatusNotFound {
+		err = ErrFileNotFound
+	}
+	if err != nil {
+		page = nil
+	}
+	return
+}
@@ -0,0 +1,256 @@
+package goseaweedfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// ArchiveFormat format of archive.
+type ArchiveFormat string
+
+const (
+	// ArchiveTar tar archive.
+	ArchiveTar ArchiveFormat = "tar"
+
+	// ArchiveZip zip archive.
+	ArchiveZip ArchiveFormat = "zip"
+)
+
+// ErrUnsupportedArchiveFormat return when archive format is neither tar nor zip.
+var ErrUnsupportedArchiveFormat = fmt.Errorf("Unsupported archive format")
+
+// archiveWriter abstracts tar/zip writers.
+type archiveWriter interface {
+	addDir(name string, fi *FileInfo) error
+	addFile(name string, fi *FileInfo) (io.Writer, error)
+	Close() error
+}
+
+// DownloadArchive walks remote directory and streams its content as an archive into w, built on the fly,
+// so nothing is staged on local disk. Entry names are relative to dir.
+func (f *Filer) DownloadArchive(dir string, format ArchiveFormat, w io.Writer) (err error) {
+	var aw archiveWriter
+	switch format {
+	case ArchiveTar:
+		aw = &tarArchiveWriter{Writer: tar.NewWriter(w)}
+	case ArchiveZip:
+		aw = &zipArchiveWriter{Writer: zip.NewWriter(w)}
+	default:
+		return ErrUnsupportedArchiveFormat
+	}
+
+	root := path.Clean("/" + dir)
+	err = f.Walk(context.Background(), root, func(p string, fi *FileInfo, err error) error {
+		if err != nil || p == root {
+			return err
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+
+		if fi.IsDir() {
+			return aw.addDir(name, fi)
+		}
+
+		var dst io.Writer
+		if dst, err = aw.addFile(name, fi); err == nil {
+			err = f.Download(p, nil, func(r io.Reader) (err error) {
+				_, err = io.Copy(dst, r)
+				return
+			})
+		}
+		return err
+	})
+
+	if e := aw.Close(); err == nil {
+		err = e
+	}
+	return
+}
+
+type tarArchiveWriter struct {
+	*tar.Writer
+}
+
+func (t *tarArchiveWriter) addDir(name string, fi *FileInfo) error {
+	return t.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     name + "/",
+		Mode:     int64(fi.Mode().Perm()),
+		ModTime:  fi.ModTime(),
+	})
+}
+
+func (t *tarArchiveWriter) addFile(name string, fi *FileInfo) (io.Writer, error) {
+	err := t.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     fi.Size(),
+		Mode:     int64(fi.Mode().Perm()),
+		ModTime:  fi.ModTime(),
+	})
+	return t.Writer, err
+}
+
+type zipArchiveWriter struct {
+	*zip.Writer
+}
+
+func (z *zipArchiveWriter) addDir(name string, fi *FileInfo) (err error) {
+	_, err = z.CreateHeader(&zip.FileHeader{
+		Name:     name + "/",
+		Modified: fi.ModTime(),
+	})
+	return
+}
+
+func (z *zipArchiveWriter) addFile(name string, fi *FileInfo) (io.Writer, error) {
+	h := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: fi.ModTime(),
+	}
+	h.SetMode(fi.Mode())
+	return z.CreateHeader(h)
+}
+
+// maxBufferedArchiveEntry is the max size of tar entries which are buffered in memory to be uploaded concurrently.
+// Bigger entries are streamed one by one.
+const maxBufferedArchiveEntry = 4 << 20
+
+// UploadArchive reads a tar/zip stream and creates corresponding file tree under destDir, uploading entries
+// with bounded concurrency. Non-positive concurrency defaults to number of CPUs.
+// Zip archives require random access: r is used directly if it is an io.ReaderAt + io.Seeker (e.g. *os.File),
+// otherwise it is spooled to a temporary file first.
+func (f *Filer) UploadArchive(r io.Reader, format ArchiveFormat, destDir string, concurrency int) (err error) {
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	g := newTaskGroup(concurrency)
+
+	switch format {
+	case ArchiveTar:
+		err = f.uploadTar(tar.NewReader(r), destDir, g)
+	case ArchiveZip:
+		err = f.uploadZip(r, destDir, g)
+	default:
+		return ErrUnsupportedArchiveFormat
+	}
+
+	if e := g.Wait(); err == nil {
+		err = e
+	}
+	return
+}
+
+func (f *Filer) uploadTar(tr *tar.Reader, destDir string, g *taskGroup) error {
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := archiveEntryPath(destDir, h.Name)
+		switch h.Typeflag {
+		case tar.TypeDir:
+			g.Go(func() error { return f.Mkdir(name) })
+
+		case tar.TypeReg:
+			if h.Size > maxBufferedArchiveEntry {
+				if err = f.uploadArchiveEntry(tr, h.Size, name); err != nil {
+					return err
+				}
+				continue
+			}
+
+			data := make([]byte, h.Size)
+			if _, err = io.ReadFull(tr, data); err != nil {
+				return err
+			}
+			g.Go(func() error { return f.uploadArchiveEntry(bytes.NewReader(data), int64(len(data)), name) })
+		}
+
+		if err = g.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+func (f *Filer) uploadZip(r io.Reader, destDir string, g *taskGroup) (err error) {
+	ra, ok := r.(interface {
+		io.ReaderAt
+		io.Seeker
+	})
+	if !ok {
+		var tmp *os.File
+		if tmp, err = ioutil.TempFile("", "goseaweedfs-archive-"); err != nil {
+			return
+		}
+		defer func() {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}()
+
+		if _, err = io.Copy(tmp, r); err != nil {
+			return
+		}
+		ra = tmp
+	}
+
+	size, err := ra.Seek(0, io.SeekEnd)
+	if err != nil {
+		return
+	}
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return
+	}
+
+	for _, zf := range zr.File {
+		zf, name := zf, archiveEntryPath(destDir, zf.Name)
+
+		if zf.FileInfo().IsDir() {
+			g.Go(func() error { return f.Mkdir(name) })
+		} else {
+			g.Go(func() error {
+				rc, err := zf.Open()
+				if err != nil {
+					return err
+				}
+				defer rc.Close()
+				return f.uploadArchiveEntry(rc, int64(zf.UncompressedSize64), name)
+			})
+		}
+
+		if err = g.Err(); err != nil {
+			return
+		}
+	}
+
+	// temporary file must not be removed before uploads finished
+	return g.Wait()
+}
+
+func (f *Filer) uploadArchiveEntry(r io.Reader, size int64, name string) error {
+	result, err := f.Upload(r, size, name, "", "")
+	if err == nil && result.Error != "" {
+		err = fmt.Errorf("Upload %s: %s", name, result.Error)
+	}
+	return err
+}
+
+// archiveEntryPath joins entry name into destDir, preventing entries from escaping it.
+func archiveEntryPath(destDir, name string) string {
+	return path.Join("/", destDir, path.Clean("/"+name))
+}
@@ -0,0 +1,121 @@
+package goseaweedfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveEntryPath(t *testing.T) {
+	require.Equal(t, "/dst/a/b.txt", archiveEntryPath("/dst", "a/b.txt"))
+	require.Equal(t, "/dst/a", archiveEntryPath("dst", "./a/"))
+	require.Equal(t, "/dst/etc/passwd", archiveEntryPath("/dst", "../../etc/passwd"))
+}
+
+func TestFilerArchiveRoundTrip(t *testing.T) {
+	var mu sync.Mutex
+	files := map[string]string{"/archive/a.txt": "aaa", "/archive/sub/b.txt": "bb"}
+	dirs := map[string][]string{"/archive": {"/archive/a.txt", "/archive/sub"}, "/archive/sub": {"/archive/sub/b.txt"}}
+	entry := func(p string) *FileInfo {
+		if _, ok := dirs[p]; ok {
+			return &FileInfo{FullPath: p, FileMode: os.ModeDir | 0755}
+		}
+		return &FileInfo{FullPath: p, FileMode: 0644, FileSize: uint64(len(files[p]))}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		p := path.Clean(r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/"):
+			dirs[p] = nil
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPost:
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data, _ := ioutil.ReadAll(f)
+			files[p] = string(data)
+			fmt.Fprintf(w, `{"name":%q,"size":%d}`, path.Base(p), len(data))
+		case r.URL.Query().Get("metadata") == "true":
+			_, isDir := dirs[p]
+			if _, isFile := files[p]; !isDir && !isFile {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(entry(p))
+		case dirs[p] != nil:
+			listing := &FilerListing{Path: p}
+			for _, c := range dirs[p] {
+				listing.Entries = append(listing.Entries, entry(c))
+			}
+			_ = json.NewEncoder(w).Encode(listing)
+		default:
+			content, ok := files[p]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, content)
+		}
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	var buf bytes.Buffer
+	require.Nil(t, filer.DownloadArchive("/archive", ArchiveTar, &buf))
+	tarball := append([]byte(nil), buf.Bytes()...)
+
+	contents := make(map[string]string)
+	tr := tar.NewReader(&buf)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.Nil(t, err)
+		data, _ := ioutil.ReadAll(tr)
+		contents[h.Name] = string(data)
+	}
+	require.Equal(t, map[string]string{"a.txt": "aaa", "sub/": "", "sub/b.txt": "bb"}, contents)
+
+	buf.Reset()
+	require.Nil(t, filer.DownloadArchive("/archive", ArchiveZip, &buf))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.Nil(t, err)
+	require.Len(t, zr.File, 3)
+
+	require.Equal(t, ErrUnsupportedArchiveFormat, filer.DownloadArchive("/archive", "rar", &buf))
+
+	// re-import both formats into other dirs
+	require.Nil(t, filer.UploadArchive(bytes.NewReader(tarball), ArchiveTar, "/from-tar", 2))
+	require.Nil(t, filer.UploadArchive(bytes.NewReader(buf.Bytes()), ArchiveZip, "/from-zip", 2))
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, dir := range []string{"/from-tar", "/from-zip"} {
+		require.Equal(t, "aaa", files[dir+"/a.txt"])
+		require.Equal(t, "bb", files[dir+"/sub/b.txt"])
+		require.Contains(t, dirs, dir+"/sub")
+	}
+}
@@ -0,0 +1,177 @@
+package goseaweedfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrBatchWriterClosed return when writing to closed batch writer.
+var ErrBatchWriterClosed = fmt.Errorf("Batch writer closed")
+
+// BatchWriterOption options for batch writer.
+type BatchWriterOption struct {
+	// MaxBatchSize flushes a blob once buffered records reach this size in bytes. Default to 1MB.
+	MaxBatchSize int
+
+	// MaxDelay flushes buffered records at most this long after the first one was written. Default to 1 second.
+	MaxDelay time.Duration
+
+	// Collection and TTL of flushed blobs.
+	Collection string
+	TTL        string
+
+	// NameFunc returns file name (inside batch directory) of the seq-th flushed blob.
+	// Default to "<unix nano>-<seq>.ndjson".
+	NameFunc func(seq uint64) string
+}
+
+// BatchWriter groups many tiny records into new line delimited blobs, which are uploaded to a filer directory
+// once they reach MaxBatchSize or MaxDelay elapsed. This trades a bounded delay for far fewer requests.
+// Flushed blobs could be read back with Filer.OpenNDJSON.
+type BatchWriter struct {
+	filer *Filer
+	dir   string
+	opt   BatchWriterOption
+
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	seq    uint64
+	timer  *time.Timer
+	err    error
+	closed bool
+}
+
+// NewBatchWriter creates batch writer which flushes blobs into dir.
+func (f *Filer) NewBatchWriter(dir string, opt BatchWriterOption) *BatchWriter {
+	if opt.MaxBatchSize <= 0 {
+		opt.MaxBatchSize = 1 << 20
+	}
+	if opt.MaxDelay <= 0 {
+		opt.MaxDelay = time.Second
+	}
+	if opt.NameFunc == nil {
+		opt.NameFunc = func(seq uint64) string {
+			return strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + strconv.FormatUint(seq, 10) + ".ndjson"
+		}
+	}
+
+	return &BatchWriter{
+		filer: f,
+		dir:   dir,
+		opt:   opt,
+	}
+}
+
+// Write a record. New line is appended if record does not end with one.
+// Error of a previous background flush is returned and writer stops accepting records.
+func (w *BatchWriter) Write(record []byte) (err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrBatchWriterClosed
+	}
+	if w.err != nil {
+		return w.err
+	}
+
+	_, _ = w.buf.Write(record)
+	if len(record) == 0 || record[len(record)-1] != '\n' {
+		_ = w.buf.WriteByte('\n')
+	}
+
+	if w.buf.Len() >= w.opt.MaxBatchSize {
+		return w.flush()
+	}
+
+	if w.timer == nil {
+		var timer *time.Timer
+		timer = time.AfterFunc(w.opt.MaxDelay, func() { w.flushByTimer(timer) })
+		w.timer = timer
+	}
+	return
+}
+
+// WriteJSON writes json encoded value as a record.
+func (w *BatchWriter) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.Write(data)
+}
+
+// Flush buffered records immediately.
+func (w *BatchWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+	return w.flush()
+}
+
+// Close flushes buffered records and stops writer.
+func (w *BatchWriter) Close() (err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if err = w.err; err == nil {
+		err = w.flush()
+	}
+	return
+}
+
+func (w *BatchWriter) flushByTimer(timer *time.Timer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// records were flushed meanwhile, and the timer may have been replaced by one of later records
+	if w.timer != timer {
+		return
+	}
+
+	w.timer = nil
+	if w.err == nil {
+		_ = w.flush()
+	}
+}
+
+func (w *BatchWriter) flush() (err error) {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+
+	if w.buf.Len() == 0 {
+		return
+	}
+
+	w.seq++
+	name := path.Join(w.dir, w.opt.NameFunc(w.seq))
+
+	result, err := w.filer.Upload(bytes.NewReader(w.buf.Bytes()), int64(w.buf.Len()), name, w.opt.Collection, w.opt.TTL)
+	if err == nil && result.Error != "" {
+		err = errors.New(result.Error)
+	}
+
+	if err != nil {
+		w.err = fmt.Errorf("Flush %s: %w", name, err)
+		return w.err
+	}
+
+	w.buf.Reset()
+	return
+}
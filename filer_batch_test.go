@@ -0,0 +1,99 @@
+package goseaweedfs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// blobServer records content of every uploaded blob by path.
+func blobServer(t *testing.T) (server *httptest.Server, blobs func() map[string]string) {
+	var mu sync.Mutex
+	received := make(map[string]string)
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := ioutil.ReadAll(f)
+
+		mu.Lock()
+		received[r.URL.Path] = string(data)
+		mu.Unlock()
+		fmt.Fprintf(w, `{"name":"blob","size":%d}`, len(data))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() map[string]string {
+		mu.Lock()
+		defer mu.Unlock()
+		copied := make(map[string]string, len(received))
+		for k, v := range received {
+			copied[k] = v
+		}
+		return copied
+	}
+}
+
+func TestBatchWriterFlushBySize(t *testing.T) {
+	server, blobs := blobServer(t)
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	w := filer.NewBatchWriter("/batch", BatchWriterOption{
+		MaxBatchSize: 8,
+		MaxDelay:     time.Hour,
+		NameFunc:     func(seq uint64) string { return fmt.Sprintf("%d.ndjson", seq) },
+	})
+
+	require.Nil(t, w.Write([]byte("abc")))
+	require.Empty(t, blobs())
+	require.Nil(t, w.Write([]byte("def\n")))
+	require.Equal(t, map[string]string{"/batch/1.ndjson": "abc\ndef\n"}, blobs())
+
+	require.Nil(t, w.WriteJSON(map[string]int{"i": 1}))
+	require.Nil(t, w.Close())
+	require.Equal(t, `{"i":1}`+"\n", blobs()["/batch/2.ndjson"])
+	require.Equal(t, ErrBatchWriterClosed, w.Write([]byte("x")))
+}
+
+func TestBatchWriterFlushByTimer(t *testing.T) {
+	server, blobs := blobServer(t)
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	w := filer.NewBatchWriter("/batch", BatchWriterOption{
+		MaxDelay: 20 * time.Millisecond,
+		NameFunc: func(seq uint64) string { return fmt.Sprintf("%d.ndjson", seq) },
+	})
+
+	require.Nil(t, w.Write([]byte("a")))
+	require.Eventually(t, func() bool { return len(blobs()) == 1 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, "a\n", blobs()["/batch/1.ndjson"])
+
+	// timer which fired while an explicit flush held the lock must not clear timer of later records
+	require.Nil(t, w.Write([]byte("b")))
+	w.mu.Lock()
+	stale := w.timer
+	require.Nil(t, w.flush())
+	w.mu.Unlock()
+	require.Nil(t, w.Write([]byte("c")))
+	w.flushByTimer(stale)
+	w.mu.Lock()
+	require.NotNil(t, w.timer)
+	w.mu.Unlock()
+
+	require.Eventually(t, func() bool { return len(blobs()) == 3 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, "c\n", blobs()["/batch/3.ndjson"])
+	require.Nil(t, w.Close())
+}
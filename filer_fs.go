@@ -0,0 +1,183 @@
+package goseaweedfs
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+)
+
+// FileSystem implements http.FileSystem over a filer, so its content could be served by http.FileServer
+// or embedded into existing routers. FileSystem is also a http.Handler, which additionally sets
+// Content-Type and ETag from filer entry metadata.
+type FileSystem struct {
+	filer *Filer
+	root  string
+}
+
+// FileSystem returns http.FileSystem serving filer entries under root directory.
+func (f *Filer) FileSystem(root string) *FileSystem {
+	return &FileSystem{
+		filer: f,
+		root:  path.Join("/", root),
+	}
+}
+
+// Open a file/dir.
+func (fs *FileSystem) Open(name string) (http.File, error) {
+	fullPath := path.Join(fs.root, path.Clean("/"+name))
+
+	info, err := fs.filer.Stat(fullPath)
+	if err != nil {
+		if errors.Is(err, ErrFileNotFound) {
+			err = os.ErrNotExist
+		}
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &filerFile{
+		filer: fs.filer,
+		path:  fullPath,
+		info:  info,
+	}, nil
+}
+
+// ServeHTTP serves files with http.ServeContent, directories with http.FileServer.
+func (fs *FileSystem) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean("/" + r.URL.Path)
+
+	file, err := fs.Open(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			http.NotFound(w, r)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		}
+		return
+	}
+	defer file.Close()
+
+	info := file.(*filerFile).info
+	if info.IsDir() {
+		http.FileServer(fs).ServeHTTP(w, r)
+		return
+	}
+
+	if info.Mime != "" {
+		w.Header().Set("Content-Type", info.Mime)
+	}
+	if etag := entryETag(info); etag != "" {
+		w.Header().Set("Etag", `"`+etag+`"`)
+	}
+
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+// entryETag returns etag of entry in the same manner as filer server does.
+func entryETag(info *FileInfo) string {
+	if len(info.Md5) > 0 {
+		return hex.EncodeToString(info.Md5)
+	}
+	if len(info.Chunks) == 1 {
+		return info.Chunks[0].ETag
+	}
+	return ""
+}
+
+// filerFile implements http.File. Reads are served by range requests starting at current offset,
+// which are reopened lazily after seeking.
+type filerFile struct {
+	filer *Filer
+	path  string
+	info  *FileInfo
+
+	offset int64
+	body   io.ReadCloser
+
+	entries []os.FileInfo
+	listed  bool
+}
+
+func (f *filerFile) Close() (err error) {
+	if f.body != nil {
+		err = f.body.Close()
+		f.body = nil
+	}
+	return
+}
+
+func (f *filerFile) Read(p []byte) (n int, err error) {
+	if f.info.IsDir() {
+		return 0, &os.PathError{Op: "read", Path: f.path, Err: errors.New("is a directory")}
+	}
+	if f.offset >= f.info.Size() {
+		return 0, io.EOF
+	}
+
+	if f.body == nil {
+		if f.body, err = f.filer.openAt(context.Background(), f.path, f.offset); err != nil {
+			return
+		}
+	}
+
+	n, err = f.body.Read(p)
+	f.offset += int64(n)
+	return
+}
+
+func (f *filerFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.Size()
+	}
+
+	if offset < 0 {
+		return 0, &os.PathError{Op: "seek", Path: f.path, Err: os.ErrInvalid}
+	}
+
+	if offset != f.offset {
+		_ = f.Close()
+		f.offset = offset
+	}
+	return offset, nil
+}
+
+func (f *filerFile) Readdir(count int) (result []os.FileInfo, err error) {
+	if !f.info.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: f.path, Err: errors.New("not a directory")}
+	}
+
+	if !f.listed {
+		var entries []*FileInfo
+		if entries, err = f.filer.ListDir(f.path); err != nil {
+			return
+		}
+		for _, e := range entries {
+			f.entries = append(f.entries, e)
+		}
+		f.listed = true
+	}
+
+	if count <= 0 {
+		result, f.entries = f.entries, nil
+		return
+	}
+
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(f.entries) {
+		count = len(f.entries)
+	}
+	result, f.entries = f.entries[:count], f.entries[count:]
+	return
+}
+
+func (f *filerFile) Stat() (os.FileInfo, error) {
+	return f.info, nil
+}
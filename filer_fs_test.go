@@ -0,0 +1,87 @@
+package goseaweedfs
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilerFileSeekRead(t *testing.T) {
+	const content = "0123456789"
+	var opened int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path != "/fs/a.txt":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Query().Get("metadata") == "true":
+			fmt.Fprintf(w, `{"FullPath":"/fs/a.txt","FileSize":%d,"Mime":"text/plain","Md5":"AQI="}`, len(content))
+		default:
+			atomic.AddInt32(&opened, 1)
+			http.ServeContent(w, r, "a.txt", time.Time{}, strings.NewReader(content))
+		}
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	fs := filer.FileSystem("/fs")
+	_, err = fs.Open("/missing.txt")
+	require.True(t, os.IsNotExist(err))
+
+	file, err := fs.Open("/a.txt")
+	require.Nil(t, err)
+	defer func() { _ = file.Close() }()
+
+	buf := make([]byte, 3)
+	_, err = io.ReadFull(file, buf)
+	require.Nil(t, err)
+	require.Equal(t, "012", string(buf))
+
+	// seeking to current offset keeps the opened body
+	offset, err := file.Seek(0, io.SeekCurrent)
+	require.Nil(t, err)
+	require.EqualValues(t, 3, offset)
+	_, err = io.ReadFull(file, buf)
+	require.Nil(t, err)
+	require.Equal(t, "345", string(buf))
+	require.EqualValues(t, 1, atomic.LoadInt32(&opened))
+
+	_, err = file.Seek(-2, io.SeekEnd)
+	require.Nil(t, err)
+	rest, err := ioutil.ReadAll(file)
+	require.Nil(t, err)
+	require.Equal(t, "89", string(rest))
+	require.EqualValues(t, 2, atomic.LoadInt32(&opened))
+
+	_, err = file.Read(buf)
+	require.Equal(t, io.EOF, err)
+
+	_, err = file.Seek(-1, io.SeekStart)
+	require.NotNil(t, err)
+
+	// served with metadata of entry and range support
+	fileServer := httptest.NewServer(fs)
+	defer fileServer.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, fileServer.URL+"/a.txt", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	data, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	require.Equal(t, "2345", string(data))
+	require.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+	require.Equal(t, `"0102"`, resp.Header.Get("Etag"))
+}
@@ -0,0 +1,91 @@
+package goseaweedfs
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrInvalidToken invalid listing continuation token.
+var ErrInvalidToken = fmt.Errorf("Invalid continuation token")
+
+// Page a page of directory listing, see ListPage.
+type Page struct {
+	// Dir listed directory.
+	Dir string
+	// Entries of page.
+	Entries []*FileInfo
+	// IsLast reports whether there are no more entries after this page.
+	IsLast bool
+	// LastFileName name of the last entry of page, which next page starts after.
+	LastFileName string
+	// Token continues listing right after this page. It only depends on directory and last file name,
+	// so it could be persisted to resume long-running scans after client restarts. Empty if IsLast.
+	Token string
+}
+
+// Count number of entries of page.
+func (p *Page) Count() int {
+	return len(p.Entries)
+}
+
+// ListPage lists a page of directory, continuing after token, which is empty for the first page.
+// Limit is max number of entries, using server default if not positive.
+func (f *Filer) ListPage(dir, token string, limit int) (page *Page, err error) {
+	dir = cleanDir(dir)
+
+	lastFileName, err := decodeToken(dir, token)
+	if err != nil {
+		return
+	}
+
+	listing, err := f.listDir(dir, lastFileName, limit)
+	if err != nil {
+		return
+	}
+
+	page = &Page{
+		Dir:          dir,
+		Entries:      listing.Entries,
+		IsLast:       !listing.ShouldDisplayLoadMore || len(listing.Entries) == 0,
+		LastFileName: listing.LastFileName,
+	}
+	if page.LastFileName == "" && len(page.Entries) > 0 {
+		page.LastFileName = page.Entries[len(page.Entries)-1].Name()
+	}
+	if !page.IsLast {
+		page.Token = encodeToken(dir, page.LastFileName)
+	}
+	return
+}
+
+func cleanDir(dir string) string {
+	dir = path.Clean("/" + dir)
+	if dir != "/" {
+		dir += "/"
+	}
+	return dir
+}
+
+// encodeToken encodes listing position, binding it to directory so it could not be misused for another one.
+func encodeToken(dir, lastFileName string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(dir + "\x00" + lastFileName))
+}
+
+func decodeToken(dir, token string) (lastFileName string, err error) {
+	if token == "" {
+		return
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	parts := strings.SplitN(string(data), "\x00", 2)
+	if len(parts) != 2 || parts[0] != dir {
+		return "", fmt.Errorf("%w: not a token of %s", ErrInvalidToken, dir)
+	}
+	return parts[1], nil
+}
@@ -0,0 +1,70 @@
+package goseaweedfs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPage(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/dir/", r.URL.Path)
+
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		start := sort.SearchStrings(names, r.URL.Query().Get("lastFileName")+"\x00")
+		end := start + limit
+		if end > len(names) {
+			end = len(names)
+		}
+
+		listing := &FilerListing{Path: "/dir", Limit: limit, ShouldDisplayLoadMore: end < len(names)}
+		for _, name := range names[start:end] {
+			listing.Entries = append(listing.Entries, &FileInfo{FullPath: "/dir/" + name})
+			listing.LastFileName = name
+		}
+		_ = json.NewEncoder(w).Encode(listing)
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	page, err := filer.ListPage("dir", "", 2)
+	require.Nil(t, err)
+	require.Equal(t, 2, page.Count())
+	require.False(t, page.IsLast)
+	require.Equal(t, "b", page.LastFileName)
+
+	// token survives a new client
+	restarted, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = restarted.Close() }()
+
+	var listed []string
+	for token := page.Token; ; {
+		page, err = restarted.ListPage("/dir/", token, 2)
+		require.Nil(t, err)
+		for _, e := range page.Entries {
+			listed = append(listed, e.Name())
+		}
+		if page.IsLast {
+			require.Empty(t, page.Token)
+			break
+		}
+		token = page.Token
+	}
+	require.Equal(t, []string{"c", "d", "e"}, listed)
+
+	_, err = filer.ListPage("/other", encodeToken("/dir/", "b"), 2)
+	require.True(t, errors.Is(err, ErrInvalidToken))
+	_, err = filer.ListPage("/dir", "!", 2)
+	require.True(t, errors.Is(err, ErrInvalidToken))
+}
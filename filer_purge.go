@@ -0,0 +1,211 @@
+package goseaweedfs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultPurgeConcurrency is the default number of concurrent delete requests of PurgeDir.
+const DefaultPurgeConcurrency = 16
+
+// maxPurgeErrors bounds failures kept in PurgeResult, as failing a multi-million-entry purge should not eat memory.
+const maxPurgeErrors = 100
+
+// ErrPurgeIncomplete returned when some entries could not be deleted by PurgeDir, see PurgeResult.
+var ErrPurgeIncomplete = fmt.Errorf("Purge incomplete")
+
+type purgeOptions struct {
+	concurrency int
+	qps         float64
+	progress    func(path string, err error)
+}
+
+// PurgeOption customizes PurgeDir.
+type PurgeOption func(*purgeOptions)
+
+// WithPurgeConcurrency bounds number of concurrent delete requests. Default to DefaultPurgeConcurrency.
+func WithPurgeConcurrency(concurrency int) PurgeOption {
+	return func(o *purgeOptions) {
+		if concurrency > 0 {
+			o.concurrency = concurrency
+		}
+	}
+}
+
+// WithPurgeRate limits delete requests to qps per second, sparing filer and its store. Listing is not limited.
+func WithPurgeRate(qps float64) PurgeOption {
+	return func(o *purgeOptions) {
+		o.qps = qps
+	}
+}
+
+// WithPurgeProgress calls fn after every deletion attempt, with error if it failed. Calls are serialized.
+func WithPurgeProgress(fn func(path string, err error)) PurgeOption {
+	return func(o *purgeOptions) {
+		o.progress = fn
+	}
+}
+
+// PurgeResult progress of PurgeDir.
+type PurgeResult struct {
+	Deleted int64
+	Failed  int64
+
+	// Errors of failed entries by path, up to first 100 failures.
+	Errors map[string]error
+}
+
+// PurgeDir deletes everything under dir, keeping dir itself. Directories are listed page by page and their
+// entries deleted with bounded concurrency (see WithPurgeConcurrency, WithPurgeRate), children before parents.
+// Failing entries do not stop purging; they are reported in result, with ErrPurgeIncomplete, and their parent
+// directories are kept. Purge stops dispatching deletes once ctx is done.
+func (f *Filer) PurgeDir(ctx context.Context, dir string, opts ...PurgeOption) (result *PurgeResult, err error) {
+	o := &purgeOptions{concurrency: DefaultPurgeConcurrency}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+
+	p := &purger{
+		f:      f,
+		ctx:    ctx,
+		opts:   o,
+		sem:    make(chan struct{}, o.concurrency),
+		result: &PurgeResult{Errors: make(map[string]error)},
+	}
+	if o.qps > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / o.qps))
+		defer ticker.Stop()
+		p.tick = ticker.C
+	}
+
+	_, err = p.purge(path.Clean("/" + dir))
+
+	result = p.result
+	if err == nil && result.Failed > 0 {
+		err = fmt.Errorf("%w: %d entries failed", ErrPurgeIncomplete, result.Failed)
+	}
+	return
+}
+
+type purger struct {
+	f    *Filer
+	ctx  context.Context
+	opts *purgeOptions
+	sem  chan struct{}
+	tick <-chan time.Time
+
+	mu     sync.Mutex
+	result *PurgeResult
+}
+
+// purge deletes entries under dir, waiting for them. Returns whether some of them failed, or error if dir
+// could not be listed or ctx is done.
+func (p *purger) purge(dir string) (failed bool, err error) {
+	var wg sync.WaitGroup
+	var failures int32
+	defer func() {
+		wg.Wait()
+		failed = failed || atomic.LoadInt32(&failures) > 0
+	}()
+
+	for lastFileName := ""; ; {
+		if err = p.ctx.Err(); err != nil {
+			return
+		}
+
+		var page *FilerListing
+		if page, err = p.f.listDir(dir, lastFileName, 0); err != nil {
+			return
+		}
+
+		for _, entry := range page.Entries {
+			child := path.Join(dir, entry.Name())
+			if entry.IsDir() {
+				childFailed, e := p.purge(child)
+				if e != nil && p.ctx.Err() != nil {
+					return false, p.ctx.Err()
+				}
+				if e != nil {
+					p.record(child, e)
+				}
+				if e != nil || childFailed {
+					failed = true
+					continue
+				}
+			}
+
+			if err = p.acquire(); err != nil {
+				return
+			}
+			wg.Add(1)
+			go func(child string, isDir bool) {
+				defer func() {
+					<-p.sem
+					wg.Done()
+				}()
+				if !p.delete(child, isDir) {
+					atomic.StoreInt32(&failures, 1)
+				}
+			}(child, entry.IsDir())
+		}
+
+		if !page.ShouldDisplayLoadMore || len(page.Entries) == 0 {
+			return
+		}
+		lastFileName = page.LastFileName
+	}
+}
+
+// acquire waits for rate limit and a free slot.
+func (p *purger) acquire() error {
+	if p.tick != nil {
+		select {
+		case <-p.tick:
+		case <-p.ctx.Done():
+			return p.ctx.Err()
+		}
+	}
+
+	select {
+	case p.sem <- struct{}{}:
+		return nil
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	}
+}
+
+func (p *purger) delete(target string, isDir bool) bool {
+	var args url.Values
+	if isDir { // already emptied, anything written meanwhile goes as well
+		args = url.Values{"recursive": []string{"true"}}
+	}
+
+	_, err := p.f.client.delete(encodeURI(*p.f.base, target, args))
+	p.record(target, err)
+	return err == nil
+}
+
+func (p *purger) record(target string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		p.result.Deleted++
+	} else {
+		p.result.Failed++
+		if len(p.result.Errors) < maxPurgeErrors {
+			p.result.Errors[target] = err
+		}
+	}
+
+	if p.opts.progress != nil {
+		p.opts.progress(target, err)
+	}
+}
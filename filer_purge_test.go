@@ -0,0 +1,164 @@
+package goseaweedfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newPurgeTree serves a mutable filer tree listing two entries per page. Deleting paths in failing fails,
+// deleting a non-empty directory is recorded as a violation of children-first order.
+func newPurgeTree(t *testing.T, failing map[string]bool, paths ...string) (*Filer, func() (remaining []string, violations []string, maxRunning int)) {
+	var mu sync.Mutex
+	var violations []string
+	running, maxRunning := 0, 0
+	entries := map[string]*FileInfo{"/": {FullPath: "/", FileMode: os.ModeDir | 0755}}
+	for _, p := range paths {
+		mode := os.FileMode(0644)
+		if strings.HasSuffix(p, "/") {
+			p, mode = strings.TrimSuffix(p, "/"), os.ModeDir|0755
+		}
+		entries[p] = &FileInfo{FullPath: p, FileMode: mode}
+	}
+	children := func(p string) (c []string) {
+		for e := range entries {
+			if e != "/" && path.Dir(e) == p {
+				c = append(c, e)
+			}
+		}
+		sort.Strings(c)
+		return
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := path.Clean(r.URL.Path)
+		if r.Method == http.MethodDelete {
+			mu.Lock()
+			if running++; running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			running--
+			if failing[p] {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if len(children(p)) > 0 {
+				violations = append(violations, p)
+			}
+			for e := range entries {
+				if e == p || strings.HasPrefix(e, p+"/") {
+					delete(entries, e)
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := entries[p]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		all := children(p)
+		start := sort.SearchStrings(all, path.Join(p, r.URL.Query().Get("lastFileName"))+"\x00")
+		if r.URL.Query().Get("lastFileName") == "" {
+			start = 0
+		}
+		end := start + 2
+		if end > len(all) {
+			end = len(all)
+		}
+
+		listing := &FilerListing{Path: p, ShouldDisplayLoadMore: end < len(all)}
+		for _, c := range all[start:end] {
+			listing.Entries = append(listing.Entries, entries[c])
+			listing.LastFileName = path.Base(c)
+		}
+		_ = json.NewEncoder(w).Encode(listing)
+	}))
+	t.Cleanup(server.Close)
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = filer.Close() })
+
+	return filer, func() ([]string, []string, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		var remaining []string
+		for e := range entries {
+			remaining = append(remaining, e)
+		}
+		sort.Strings(remaining)
+		return remaining, violations, maxRunning
+	}
+}
+
+func TestFilerPurgeDir(t *testing.T) {
+	paths := []string{"/keep", "/data/", "/data/sub/", "/data/sub/deep/", "/data/bad/", "/data/bad/ok", "/data/bad/locked"}
+	for i := 0; i < 30; i++ {
+		paths = append(paths, fmt.Sprintf("/data/%02d", i), fmt.Sprintf("/data/sub/deep/%02d", i))
+	}
+	filer, state := newPurgeTree(t, map[string]bool{"/data/bad/locked": true}, paths...)
+
+	var progressed int
+	result, err := filer.PurgeDir(context.Background(), "data", WithPurgeConcurrency(4), WithPurgeProgress(func(string, error) {
+		progressed++
+	}))
+	require.True(t, errors.Is(err, ErrPurgeIncomplete), "%v", err)
+	require.EqualValues(t, 63, result.Deleted)
+	require.EqualValues(t, 1, result.Failed)
+	require.Contains(t, result.Errors, "/data/bad/locked")
+	require.Equal(t, 64, progressed)
+
+	remaining, violations, maxRunning := state()
+	require.Equal(t, []string{"/", "/data", "/data/bad", "/data/bad/locked", "/keep"}, remaining)
+	require.Empty(t, violations)
+	require.LessOrEqual(t, maxRunning, 4)
+	require.Greater(t, maxRunning, 1)
+
+	_, err = filer.PurgeDir(context.Background(), "/missing")
+	require.True(t, errors.Is(err, ErrFileNotFound))
+}
+
+func TestFilerPurgeDirRate(t *testing.T) {
+	filer, state := newPurgeTree(t, nil, "/data/", "/data/1", "/data/2", "/data/3", "/data/4", "/data/5")
+
+	start := time.Now()
+	result, err := filer.PurgeDir(context.Background(), "/data", WithPurgeRate(100))
+	require.Nil(t, err)
+	require.EqualValues(t, 5, result.Deleted)
+	require.GreaterOrEqual(t, int64(time.Since(start)), int64(40*time.Millisecond))
+
+	remaining, _, _ := state()
+	require.Equal(t, []string{"/", "/data"}, remaining)
+
+	// canceled purge stops dispatching
+	filer, state = newPurgeTree(t, nil, "/data/", "/data/1", "/data/2", "/data/3")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = filer.PurgeDir(ctx, "/data")
+	require.True(t, errors.Is(err, context.Canceled))
+
+	remaining, _, _ = state()
+	require.Len(t, remaining, 5)
+}
@@ -0,0 +1,184 @@
+package goseaweedfs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+// DefaultMaxRecordSize is the default upper bound of a single csv record or ndjson line.
+const DefaultMaxRecordSize = 1 << 20
+
+// ErrRecordTooLarge return when a record exceeds max record size.
+var ErrRecordTooLarge = fmt.Errorf("Record too large")
+
+// errRangeNotSatisfiable return when a range request starts at or beyond end of file.
+var errRangeNotSatisfiable = fmt.Errorf("Range not satisfiable")
+
+type recordReader struct {
+	body   io.ReadCloser
+	r      *bufio.Reader
+	buf    []byte
+	offset int64
+
+	// MaxRecordSize bounds memory used for a single record. Default to DefaultMaxRecordSize.
+	MaxRecordSize int
+}
+
+func newRecordReader(body io.ReadCloser, offset int64) *recordReader {
+	return &recordReader{
+		body:          body,
+		r:             bufio.NewReader(body),
+		offset:        offset,
+		MaxRecordSize: DefaultMaxRecordSize,
+	}
+}
+
+// Offset returns byte offset right after the last returned record.
+// Passing it to Open* methods resumes reading from next record.
+func (r *recordReader) Offset() int64 {
+	return r.offset
+}
+
+// Close underlying body.
+func (r *recordReader) Close() error {
+	return r.body.Close()
+}
+
+// readLine reads next line (including trailing new line) and appends it to current buffer.
+func (r *recordReader) readLine() (err error) {
+	for {
+		var frag []byte
+		frag, err = r.r.ReadSlice('\n')
+		if len(r.buf)+len(frag) > r.MaxRecordSize {
+			return ErrRecordTooLarge
+		}
+		r.buf = append(r.buf, frag...)
+
+		if err != bufio.ErrBufferFull {
+			if err == io.EOF && len(r.buf) > 0 {
+				err = nil
+			}
+			return
+		}
+	}
+}
+
+// CSVReader reads csv records from a stored file with bounded memory.
+type CSVReader struct {
+	*recordReader
+
+	// Comma is the field delimiter. Default to ','.
+	Comma rune
+}
+
+// Read next record. Returns io.EOF at end of file.
+func (r *CSVReader) Read() (record []string, err error) {
+	for {
+		r.buf = r.buf[:0]
+
+		// quoted fields might contain new lines, keep reading until quotes are balanced
+		for {
+			if err = r.readLine(); err != nil {
+				return
+			}
+			if bytes.Count(r.buf, []byte{'"'})%2 == 0 {
+				break
+			}
+			if r.buf[len(r.buf)-1] != '\n' { // unterminated quote at end of file
+				break
+			}
+		}
+		r.offset += int64(len(r.buf))
+
+		cr := csv.NewReader(bytes.NewReader(r.buf))
+		cr.Comma = r.Comma
+		cr.FieldsPerRecord = -1
+		if record, err = cr.Read(); err != io.EOF { // io.EOF means empty line
+			return
+		}
+	}
+}
+
+// NDJSONReader reads new line delimited json values from a stored file with bounded memory.
+type NDJSONReader struct {
+	*recordReader
+}
+
+// Next returns next non-empty line, without trailing new line. Returns io.EOF at end of file.
+// Returned slice is only valid until next call.
+func (r *NDJSONReader) Next() (line []byte, err error) {
+	for {
+		r.buf = r.buf[:0]
+		if err = r.readLine(); err != nil {
+			return
+		}
+		r.offset += int64(len(r.buf))
+
+		if line = bytes.TrimSpace(r.buf); len(line) > 0 {
+			return
+		}
+	}
+}
+
+// Decode next line into v. Returns io.EOF at end of file.
+func (r *NDJSONReader) Decode(v interface{}) (err error) {
+	line, err := r.Next()
+	if err == nil {
+		err = json.Unmarshal(line, v)
+	}
+	return
+}
+
+// OpenCSV opens csv file at path, starting from byte offset.
+func (f *Filer) OpenCSV(path string, offset int64) (r *CSVReader, err error) {
+	body, err := f.openAt(context.Background(), path, offset)
+	if err == nil {
+		r = &CSVReader{recordReader: newRecordReader(body, offset), Comma: ','}
+	}
+	return
+}
+
+// OpenNDJSON opens new line delimited json file at path, starting from byte offset.
+func (f *Filer) OpenNDJSON(path string, offset int64) (r *NDJSONReader, err error) {
+	body, err := f.openAt(context.Background(), path, offset)
+	if err == nil {
+		r = &NDJSONReader{recordReader: newRecordReader(body, offset)}
+	}
+	return
+}
+
+func (f *Filer) openAt(ctx context.Context, path string, offset int64) (body io.ReadCloser, err error) {
+	var header http.Header
+	if offset > 0 {
+		header = http.Header{"Range": []string{"bytes=" + strconv.FormatInt(offset, 10) + "-"}}
+	}
+
+	result, err := f.client.fetchContext(ctx, http.MethodGet, encodeURI(*f.base, path, nil), header)
+	if offset > 0 && errors.Is(err, errRangeNotSatisfiable) {
+		// resuming right at the end of file, nothing to read yet
+		return http.NoBody, nil
+	}
+	if err != nil {
+		return
+	}
+
+	// server ignored range request, skip to offset manually
+	if offset > 0 && result.Header.Get("Content-Range") == "" {
+		if _, err = io.CopyN(ioutil.Discard, result.Body, offset); err != nil {
+			_ = result.Close()
+			return
+		}
+	}
+
+	body = result.Body
+	return
+}
@@ -0,0 +1,87 @@
+package goseaweedfs
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVReader(t *testing.T) {
+	content := "a,b,c\n\n\"multi\nline\",\"quote \"\"x\"\"\",3\nlast,1"
+
+	r := &CSVReader{recordReader: newRecordReader(ioutil.NopCloser(bytes.NewBufferString(content)), 0), Comma: ','}
+	record, err := r.Read()
+	require.Nil(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, record)
+	require.EqualValues(t, 6, r.Offset())
+
+	record, err = r.Read()
+	require.Nil(t, err)
+	require.Equal(t, []string{"multi\nline", `quote "x"`, "3"}, record)
+
+	// resume from offset
+	offset := r.Offset()
+	r = &CSVReader{recordReader: newRecordReader(ioutil.NopCloser(bytes.NewBufferString(content[offset:])), offset), Comma: ','}
+	record, err = r.Read()
+	require.Nil(t, err)
+	require.Equal(t, []string{"last", "1"}, record)
+	require.EqualValues(t, len(content), r.Offset())
+
+	_, err = r.Read()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestNDJSONReader(t *testing.T) {
+	content := `{"name":"a"}` + "\n\n" + `{"name":"b"}` + "\n"
+
+	r := &NDJSONReader{recordReader: newRecordReader(ioutil.NopCloser(bytes.NewBufferString(content)), 0)}
+
+	var v struct {
+		Name string `json:"name"`
+	}
+	require.Nil(t, r.Decode(&v))
+	require.Equal(t, "a", v.Name)
+	require.Nil(t, r.Decode(&v))
+	require.Equal(t, "b", v.Name)
+	require.EqualValues(t, len(content), r.Offset())
+	require.Equal(t, io.EOF, r.Decode(&v))
+
+	r = &NDJSONReader{recordReader: newRecordReader(ioutil.NopCloser(bytes.NewBufferString(content)), 0)}
+	r.MaxRecordSize = 4
+	_, err := r.Next()
+	require.Equal(t, ErrRecordTooLarge, err)
+}
+
+func TestOpenAtEnd(t *testing.T) {
+	content := "a,b\nc,d\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// answers 416 for a range starting at end of content, like filer does
+		http.ServeContent(w, r, "a.csv", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	r, err := filer.OpenCSV("/a.csv", 4)
+	require.Nil(t, err)
+	record, err := r.Read()
+	require.Nil(t, err)
+	require.Equal(t, []string{"c", "d"}, record)
+	require.Nil(t, r.Close())
+
+	r, err = filer.OpenCSV("/a.csv", int64(len(content)))
+	require.Nil(t, err)
+	_, err = r.Read()
+	require.Equal(t, io.EOF, err)
+	require.EqualValues(t, len(content), r.Offset())
+	require.Nil(t, r.Close())
+}
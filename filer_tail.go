@@ -0,0 +1,167 @@
+package goseaweedfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultTailPollInterval is the default interval of polling size of a tailed file.
+const DefaultTailPollInterval = time.Second
+
+// TailReader follows a growing file, see Filer.Tail.
+type TailReader struct {
+	filer *Filer
+	path  string
+	ctx   context.Context
+
+	// PollInterval of checking file size while no new content is available. Could be changed before reading.
+	PollInterval time.Duration
+
+	mu     sync.Mutex
+	offset int64
+	body   io.ReadCloser
+	closed bool
+	done   chan struct{}
+}
+
+// Tail follows file at path from offset as it grows, like `tail -F`. Read blocks until new content is
+// appended, ctx is done, or reader is closed. File which does not exist yet is waited for, and file
+// which shrinks below current offset (e.g. truncated or replaced) is followed again from its start.
+func (f *Filer) Tail(ctx context.Context, path string, fromOffset int64) *TailReader {
+	return &TailReader{
+		filer:        f,
+		path:         path,
+		ctx:          ctx,
+		PollInterval: DefaultTailPollInterval,
+		offset:       fromOffset,
+		done:         make(chan struct{}),
+	}
+}
+
+// Offset returns offset of the next byte to be read, which could be used to resume tailing later.
+func (t *TailReader) Offset() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.offset
+}
+
+// Read new content of file, blocking until some is available.
+func (t *TailReader) Read(p []byte) (n int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for {
+		if t.closed {
+			return 0, ErrReaderClosed
+		}
+
+		if t.body != nil {
+			n, err = t.body.Read(p)
+			t.offset += int64(n)
+			if err == io.EOF {
+				_ = t.body.Close()
+				t.body, err = nil, nil
+			}
+			if n > 0 || err != nil {
+				return
+			}
+		}
+
+		// stat and open without holding the lock, so reader could be closed meanwhile
+		offset := t.offset
+		t.mu.Unlock()
+		body, from, openErr := t.open(offset)
+		t.mu.Lock()
+		if openErr != nil {
+			if e := t.ctx.Err(); e != nil {
+				openErr = e // report cancellation like wait does, not as a failed request
+			}
+			return 0, openErr
+		}
+
+		if body != nil {
+			if t.closed || t.body != nil || t.offset != offset {
+				// raced with Close or a concurrent Read, start over
+				_ = body.Close()
+			} else {
+				t.body, t.offset = body, from
+			}
+			continue
+		}
+
+		// nothing new, wait without holding the lock
+		t.mu.Unlock()
+		err = t.wait()
+		t.mu.Lock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// open starts reading content appended after offset, if any, returning the offset body starts from.
+// Body is nil if there is nothing new yet.
+func (t *TailReader) open(offset int64) (body io.ReadCloser, from int64, err error) {
+	fi, err := t.filer.stat(t.ctx, t.path)
+	if errors.Is(err, ErrFileNotFound) {
+		return nil, offset, nil
+	}
+	if err != nil {
+		return
+	}
+
+	size, from := fi.Size(), offset
+	if size < from {
+		from = 0
+	}
+	if size == from {
+		return
+	}
+
+	rc, err := t.filer.openAt(t.ctx, t.path, from)
+	if err != nil {
+		return
+	}
+
+	// read up to the observed size only, content being appended is picked up by next poll
+	body = &limitedReadCloser{Reader: io.LimitReader(rc, size-from), Closer: rc}
+	return
+}
+
+func (t *TailReader) wait() error {
+	timer := time.NewTimer(t.PollInterval)
+	defer timer.Stop()
+
+	select {
+	case <-t.ctx.Done():
+		return t.ctx.Err()
+	case <-t.done:
+		return ErrReaderClosed
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Close stops tailing.
+func (t *TailReader) Close() (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.closed {
+		t.closed = true
+		close(t.done)
+	}
+	if t.body != nil {
+		err = t.body.Close()
+		t.body = nil
+	}
+	return
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,154 @@
+package goseaweedfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilerTail(t *testing.T) {
+	var mu sync.Mutex
+	var content string
+	exists := false
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("metadata") == "true" {
+			fmt.Fprintf(w, `{"FullPath":"/log.txt","FileSize":%d}`, len(content))
+			return
+		}
+
+		offset := 0
+		if rg := r.Header.Get("Range"); rg != "" {
+			offset, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rg, "bytes="), "-"))
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		fmt.Fprint(w, content[offset:])
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	appendContent := func(s string) {
+		mu.Lock()
+		content, exists = content+s, true
+		mu.Unlock()
+	}
+	readN := func(r io.Reader, n int) string {
+		buf := make([]byte, n)
+		_, err := io.ReadFull(r, buf)
+		require.Nil(t, err)
+		return string(buf)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tail := filer.Tail(ctx, "/log.txt", 0)
+	tail.PollInterval = 5 * time.Millisecond
+
+	// file does not exist yet
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		appendContent("line 1\n")
+	}()
+	require.Equal(t, "line 1\n", readN(tail, 7))
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		appendContent("line 2\n")
+	}()
+	require.Equal(t, "line 2\n", readN(tail, 7))
+	require.EqualValues(t, 14, tail.Offset())
+
+	// truncated: follow from start
+	mu.Lock()
+	content = "new\n"
+	mu.Unlock()
+	require.Equal(t, "new\n", readN(tail, 4))
+
+	// resume from offset
+	resumed := filer.Tail(ctx, "/log.txt", 1)
+	resumed.PollInterval = 5 * time.Millisecond
+	require.Equal(t, "ew\n", readN(resumed, 3))
+
+	// close unblocks waiting read
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = tail.Close()
+	}()
+	_, err = tail.Read(make([]byte, 1))
+	require.True(t, errors.Is(err, ErrReaderClosed))
+
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	_, err = filer.Tail(cancelled, "/log.txt", 4).Read(make([]byte, 1))
+	require.Equal(t, context.Canceled, err)
+}
+
+func TestFilerTailUnlockedIO(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tail := filer.Tail(ctx, "/log.txt", 0)
+	errc := make(chan error, 1)
+	go func() {
+		_, err := tail.Read(make([]byte, 1))
+		errc <- err
+	}()
+
+	// stat is in flight: close must not wait for it, cancelling ctx aborts it
+	time.Sleep(20 * time.Millisecond)
+	closed := make(chan struct{})
+	go func() {
+		_ = tail.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked by in-flight request")
+	}
+
+	cancel()
+	select {
+	case err = <-errc:
+		require.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("Read not aborted by ctx")
+	}
+}
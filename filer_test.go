@@ -0,0 +1,282 @@
+package goseaweedfs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileInfo(t *testing.T) {
+	raw := `{"FullPath":"/js/test.txt","Mtime":"2020-10-28T10:00:00+09:00","Mode":432,"Mime":"text/plain","FileSize":0,
+		"chunks":[{"file_id":"3,01637037d6","size":8,"mtime":1603846800000000000},{"file_id":"3,01637037d7","offset":8,"size":4}]}`
+
+	var fi FileInfo
+	require.Nil(t, json.Unmarshal([]byte(raw), &fi))
+	require.Equal(t, "test.txt", fi.Name())
+	require.EqualValues(t, 12, fi.Size())
+	require.False(t, fi.IsDir())
+	require.Equal(t, 2020, fi.ModTime().Year())
+
+	raw = `{"FullPath":"/js","Mode":2147484141}`
+	require.Nil(t, json.Unmarshal([]byte(raw), &fi))
+	require.True(t, fi.IsDir())
+}
+
+func TestFilerDryRun(t *testing.T) {
+	var skipped []string
+	hook := func(method, url string) { skipped = append(skipped, method+" "+url) }
+
+	// unreachable filer: any request actually sent would fail
+	filer, err := NewFiler("http://127.0.0.1:1", nil, WithDryRun(hook))
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	require.Nil(t, filer.Delete("/dir", url.Values{"recursive": []string{"true"}}))
+	require.Nil(t, filer.Rename("/a.txt", "/b.txt"))
+	require.Nil(t, filer.Mkdir("/new"))
+	require.Equal(t, []string{
+		"DELETE http://127.0.0.1:1/dir?recursive=true",
+		"POST http://127.0.0.1:1/b.txt?mv.from=%2Fa.txt",
+		"POST http://127.0.0.1:1/new/",
+	}, skipped)
+}
+
+func TestDryRunUploads(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr := server.Listener.Addr().String()
+		switch {
+		case r.Method != http.MethodGet && r.Method != http.MethodHead:
+			t.Errorf("%s %s sent under dry run", r.Method, r.URL)
+		case r.URL.Path == "/dir/assign":
+			fmt.Fprintf(w, `{"fid":"3,02","url":%q,"publicUrl":%q,"count":1}`, addr, addr)
+		case r.URL.Path == "/dir/lookup":
+			fmt.Fprintf(w, `{"locations":[{"url":%q,"publicUrl":%q}]}`, addr, addr)
+		default:
+			fmt.Fprint(w, "content")
+		}
+	}))
+	defer server.Close()
+
+	var skipped []string
+	sw, err := NewSeaweed(server.URL, []string{server.URL}, 1024, server.Client(), WithDryRun(func(method, url string) {
+		skipped = append(skipped, method+" "+strings.TrimPrefix(url, server.URL))
+	}))
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+	filer := sw.Filers()[0]
+
+	_, err = filer.Upload(strings.NewReader("content"), 7, "/a.txt", "", "")
+	require.Nil(t, err)
+
+	_, err = filer.Migrate("/b.txt", "col", MigrateOption{})
+	require.Nil(t, err)
+
+	_, err = sw.Migrate("3,01", "col", MigrateOption{DeleteSource: true})
+	require.Nil(t, err)
+
+	_, err = sw.Upload(strings.NewReader("content"), "c.txt", 7, "", "", WithReplicationAck())
+	require.Nil(t, err)
+
+	require.Equal(t, []string{
+		"POST /a.txt",
+		"POST /b.txt?collection=col",
+		"POST /3,02?collection=col",
+		"DELETE /3,01",
+		"POST /3,02",
+	}, skipped)
+}
+
+func TestFilerIdempotencyKey(t *testing.T) {
+	var uploads int
+	keys := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			key, ok := keys[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set(idempotencyKeyHeader, key)
+			w.Header().Set("Content-Length", "7")
+
+		case http.MethodPost:
+			uploads++
+			keys[r.URL.Path] = r.Header.Get(idempotencyKeyHeader)
+			fmt.Fprint(w, `{"name":"a.txt","size":7}`)
+		}
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	upload := func(key string) *FilerUploadResult {
+		result, err := filer.Upload(strings.NewReader("content"), 7, "/a.txt", "", "", WithIdempotencyKey(key))
+		require.Nil(t, err)
+		require.NotNil(t, result.Header)
+		return &FilerUploadResult{Name: result.Name, Size: result.Size}
+	}
+
+	require.Equal(t, &FilerUploadResult{Name: "a.txt", Size: 7}, upload("k1"))
+	require.Equal(t, 1, uploads)
+
+	// retried with same key
+	require.Equal(t, &FilerUploadResult{Name: "a.txt", Size: 7}, upload("k1"))
+	require.Equal(t, 1, uploads)
+
+	// another write
+	upload("k2")
+	require.Equal(t, 2, uploads)
+	require.Equal(t, "k2", keys["/a.txt"])
+}
+
+func TestFilerStatMany(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"FullPath":%q,"FileSize":7}`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	paths := []string{"/missing", "/a.txt", "/b.txt", "/a.txt"}
+	for i := 0; i < 20; i++ {
+		paths = append(paths, fmt.Sprintf("/dir/%d", i))
+	}
+
+	results := filer.StatMany(paths, 4)
+	require.Len(t, results, 23)
+	require.True(t, errors.Is(results["/missing"].Err, ErrFileNotFound))
+	require.Nil(t, results["/missing"].Info)
+	require.Nil(t, results["/a.txt"].Err)
+	require.Equal(t, "a.txt", results["/a.txt"].Info.Name())
+	require.Equal(t, "/dir/19", results["/dir/19"].Info.FullPath)
+}
+
+func TestFilerStatError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"error":"access denied"}`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	fi, err := filer.Stat("/missing")
+	require.True(t, errors.Is(err, ErrFileNotFound))
+	require.Nil(t, fi)
+
+	// error responses carrying a json body must not be taken as an empty entry
+	fi, err = filer.Stat("/forbidden")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "access denied")
+	require.Nil(t, fi)
+
+	fi, err = filer.Stat("/broken")
+	require.NotNil(t, err)
+	require.False(t, errors.Is(err, ErrFileNotFound))
+	require.Nil(t, fi)
+}
+
+func TestFilerSaveInside(t *testing.T) {
+	var inline []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inline = append(inline, r.URL.Query().Get(paramSaveInside))
+		fmt.Fprint(w, `{"name":"a","size":1}`)
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client(), WithInlineThreshold(4))
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	_, err = filer.Upload(strings.NewReader("tiny"), 4, "/a.json", "", "")
+	require.Nil(t, err)
+	_, err = filer.Upload(strings.NewReader("larger"), 6, "/b.json", "", "")
+	require.Nil(t, err)
+	_, err = filer.Upload(strings.NewReader("larger"), 6, "/c.json", "", "", WithSaveInside())
+	require.Nil(t, err)
+	_, err = filer.Upload(strings.NewReader("unknown"), 0, "/d.json", "", "")
+	require.Nil(t, err)
+
+	require.Equal(t, []string{"true", "", "true", ""}, inline)
+}
+
+func TestFilerExclusiveCreate(t *testing.T) {
+	var uploads, conditional int
+	version := "SeaweedFS Filer 30GB 3.80"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", version)
+		switch {
+		case r.Method == http.MethodHead:
+			// capability detection only, existence is never checked ahead
+			require.Equal(t, "/", r.URL.Path)
+		case r.Header.Get("If-None-Match") == "*" && r.URL.Path == "/exists.txt":
+			conditional++
+			w.WriteHeader(http.StatusPreconditionFailed)
+		case r.URL.Path == "/plain.txt":
+			w.WriteHeader(http.StatusPreconditionFailed)
+		default:
+			if r.Header.Get("If-None-Match") == "*" {
+				conditional++
+			}
+			uploads++
+			fmt.Fprint(w, `{"name":"new.txt","size":7}`)
+		}
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	upload := func(filer *Filer, path string, opts ...UploadOption) error {
+		_, err := filer.Upload(strings.NewReader("content"), 7, path, "", "", opts...)
+		return err
+	}
+
+	require.True(t, errors.Is(upload(filer, "/exists.txt", WithExclusiveCreate()), ErrAlreadyExists))
+	require.Nil(t, upload(filer, "/new.txt", WithExclusiveCreate()))
+	require.Equal(t, 2, conditional)
+	require.Equal(t, 1, uploads)
+
+	// 412 without a conditional request is an ordinary failure
+	err = upload(filer, "/plain.txt")
+	require.NotNil(t, err)
+	require.False(t, errors.Is(err, ErrAlreadyExists))
+
+	// filer which would ignore the precondition is refused, nothing is written
+	version = "SeaweedFS Filer 30GB 3.59"
+	old, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = old.Close() }()
+
+	err = upload(old, "/new.txt", WithExclusiveCreate())
+	require.True(t, errors.Is(err, ErrUnsupportedByServer), "%v", err)
+	require.Equal(t, 1, uploads)
+}
@@ -0,0 +1,73 @@
+package goseaweedfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+)
+
+// WalkFunc is called by Walk for every visited entry, see filepath.WalkDirFunc. If err is non-nil,
+// fi is nil for root which could not be stated, or fi is the directory which could not be listed.
+// Returning fs.SkipDir (filepath.SkipDir) skips the directory, or the rest of parent directory if fi is a file.
+type WalkFunc func(path string, fi *FileInfo, err error) error
+
+// Walk walks the tree rooted at root, calling fn for every entry including root, mirroring filepath.WalkDir.
+// Entries are visited in lexical order, parents before children. Directories are listed page by page,
+// so memory is bounded by page size and tree depth rather than directory size. Walk stops once ctx is done.
+func (f *Filer) Walk(ctx context.Context, root string, fn WalkFunc) (err error) {
+	root = path.Clean("/" + root)
+
+	fi, err := f.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = f.walkDir(ctx, root, fi, fn)
+	}
+
+	if errors.Is(err, fs.SkipDir) {
+		return nil
+	}
+	return
+}
+
+func (f *Filer) walkDir(ctx context.Context, p string, fi *FileInfo, fn WalkFunc) (err error) {
+	if err = fn(p, fi, nil); err != nil || !fi.IsDir() {
+		if errors.Is(err, fs.SkipDir) && fi.IsDir() {
+			err = nil
+		}
+		return
+	}
+
+	for lastFileName := ""; ; {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		var page *FilerListing
+		if page, err = f.listDir(p, lastFileName, 0); err != nil {
+			if err = fn(p, fi, err); errors.Is(err, fs.SkipDir) {
+				err = nil
+			}
+			return
+		}
+
+		for _, entry := range page.Entries {
+			if err = ctx.Err(); err != nil {
+				return
+			}
+
+			if err = f.walkDir(ctx, path.Join(p, entry.Name()), entry, fn); err != nil {
+				if errors.Is(err, fs.SkipDir) { // file skipped its parent
+					err = nil
+				}
+				return
+			}
+		}
+
+		if !page.ShouldDisplayLoadMore || len(page.Entries) == 0 {
+			return
+		}
+		lastFileName = page.LastFileName
+	}
+}
@@ -0,0 +1,127 @@
+package goseaweedfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTree serves metadata and listings of a filer tree, listing two entries per page.
+func newTestTree(t *testing.T, paths ...string) *Filer {
+	entries := make(map[string]*FileInfo)
+	children := make(map[string][]string)
+	entries["/"] = &FileInfo{FullPath: "/", FileMode: os.ModeDir | 0755}
+	for _, p := range paths {
+		mode := os.FileMode(0644)
+		if strings.HasSuffix(p, "/") {
+			p, mode = strings.TrimSuffix(p, "/"), os.ModeDir|0755
+		}
+		entries[p] = &FileInfo{FullPath: p, FileMode: mode}
+		children[path.Dir(p)] = append(children[path.Dir(p)], p)
+	}
+	for _, c := range children {
+		sort.Strings(c)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := path.Clean(r.URL.Path)
+		entry, ok := entries[p]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("metadata") == "true" || !entry.IsDir() {
+			_ = json.NewEncoder(w).Encode(entry)
+			return
+		}
+
+		all := children[p]
+		start := sort.SearchStrings(all, path.Join(p, r.URL.Query().Get("lastFileName"))+"\x00")
+		if r.URL.Query().Get("lastFileName") == "" {
+			start = 0
+		}
+		end := start + 2
+		if end > len(all) {
+			end = len(all)
+		}
+
+		listing := &FilerListing{Path: p, ShouldDisplayLoadMore: end < len(all)}
+		for _, c := range all[start:end] {
+			listing.Entries = append(listing.Entries, entries[c])
+			listing.LastFileName = path.Base(c)
+		}
+		_ = json.NewEncoder(w).Encode(listing)
+	}))
+	t.Cleanup(server.Close)
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = filer.Close() })
+	return filer
+}
+
+func TestFilerWalk(t *testing.T) {
+	filer := newTestTree(t, "/a/", "/a/1", "/a/2", "/a/3", "/a/sub/", "/a/sub/x", "/b/", "/b/1", "/b/2", "/c", "/d/")
+
+	walk := func(root string, fn WalkFunc) (visited []string, err error) {
+		err = filer.Walk(context.Background(), root, func(p string, fi *FileInfo, err error) error {
+			visited = append(visited, p)
+			return fn(p, fi, err)
+		})
+		return
+	}
+	noop := func(string, *FileInfo, error) error { return nil }
+
+	visited, err := walk("/", noop)
+	require.Nil(t, err)
+	require.Equal(t, []string{"/", "/a", "/a/1", "/a/2", "/a/3", "/a/sub", "/a/sub/x", "/b", "/b/1", "/b/2", "/c", "/d"}, visited)
+
+	visited, err = walk("a", noop)
+	require.Nil(t, err)
+	require.Equal(t, []string{"/a", "/a/1", "/a/2", "/a/3", "/a/sub", "/a/sub/x"}, visited)
+
+	// skip a directory, and rest of a directory from a file
+	visited, err = walk("/", func(p string, fi *FileInfo, err error) error {
+		if p == "/a" || p == "/b/1" {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, []string{"/", "/a", "/b", "/b/1", "/c", "/d"}, visited)
+
+	// errors stop walking
+	stop := errors.New("stop")
+	visited, err = walk("/", func(p string, fi *FileInfo, err error) error {
+		if p == "/a/2" {
+			return stop
+		}
+		return nil
+	})
+	require.Equal(t, stop, err)
+	require.Equal(t, []string{"/", "/a", "/a/1", "/a/2"}, visited)
+
+	// missing root is reported to fn
+	var rootErr error
+	_, err = walk("/missing", func(p string, fi *FileInfo, err error) error {
+		rootErr = err
+		return nil
+	})
+	require.Nil(t, err)
+	require.True(t, errors.Is(rootErr, ErrFileNotFound), fmt.Sprint(rootErr))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Equal(t, context.Canceled, filer.Walk(ctx, "/", noop))
+}
@@ -0,0 +1,88 @@
+package goseaweedfs
+
+import (
+	"context"
+	"sort"
+)
+
+// VolumeGarbage garbage of a volume. Replicas are merged, taking the highest garbage ratio.
+type VolumeGarbage struct {
+	ID               uint32
+	Collection       string
+	Servers          []string
+	Size             uint64
+	DeletedByteCount uint64
+	ReadOnly         bool
+
+	// Ratio of deleted bytes to volume size, which is compared against vacuum garbage threshold.
+	Ratio float64
+}
+
+// GarbageStatus garbage summary of cluster.
+type GarbageStatus struct {
+	// Volumes ordered by garbage ratio, highest first.
+	Volumes          []*VolumeGarbage
+	Size             uint64
+	DeletedByteCount uint64
+}
+
+// Ratio of deleted bytes to size over all volumes.
+func (g *GarbageStatus) Ratio() float64 {
+	return garbageRatio(g.DeletedByteCount, g.Size)
+}
+
+// Above returns volumes whose garbage ratio exceeds threshold, i.e. those vacuum (see GC) would compact.
+func (g *GarbageStatus) Above(threshold float64) (volumes []*VolumeGarbage) {
+	for _, v := range g.Volumes {
+		if v.Ratio > threshold {
+			volumes = append(volumes, v)
+		}
+	}
+	return
+}
+
+func garbageRatio(deleted, size uint64) float64 {
+	if size == 0 {
+		return 0
+	}
+	return float64(deleted) / float64(size)
+}
+
+// GarbageStatus summarizes garbage ratio per volume, helping to decide when to trigger vacuum via GC.
+func (c *Seaweed) GarbageStatus(ctx context.Context) (status *GarbageStatus, err error) {
+	replicas, err := c.VolumeStatus(ctx)
+	if err != nil {
+		return
+	}
+
+	volumes := make(map[uint32]*VolumeGarbage)
+	for _, r := range replicas {
+		ratio := garbageRatio(r.DeletedByteCount, r.Size)
+
+		v, ok := volumes[r.ID]
+		if !ok {
+			v = &VolumeGarbage{ID: r.ID, Collection: r.Collection, Ratio: -1}
+			volumes[r.ID] = v
+		}
+		v.Servers = append(v.Servers, r.Server)
+		v.ReadOnly = v.ReadOnly || r.ReadOnly
+		if ratio > v.Ratio {
+			v.Size, v.DeletedByteCount, v.Ratio = r.Size, r.DeletedByteCount, ratio
+		}
+	}
+
+	status = &GarbageStatus{Volumes: make([]*VolumeGarbage, 0, len(volumes))}
+	for _, v := range volumes {
+		status.Volumes = append(status.Volumes, v)
+		status.Size += v.Size
+		status.DeletedByteCount += v.DeletedByteCount
+	}
+	sort.Slice(status.Volumes, func(i, j int) bool {
+		a, b := status.Volumes[i], status.Volumes[j]
+		if a.Ratio != b.Ratio {
+			return a.Ratio > b.Ratio
+		}
+		return a.ID < b.ID
+	})
+	return
+}
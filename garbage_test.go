@@ -0,0 +1,37 @@
+package goseaweedfs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGarbageStatus(t *testing.T) {
+	sw, done := newTestMaster(t, map[string]string{"/vol/status": testVolumeStatus})
+	defer done()
+
+	status, err := sw.GarbageStatus(context.Background())
+	require.Nil(t, err)
+	require.Len(t, status.Volumes, 3)
+
+	require.EqualValues(t, 3, status.Volumes[0].ID)
+	require.Equal(t, 0.5, status.Volumes[0].Ratio)
+
+	// replica with higher garbage ratio wins
+	require.EqualValues(t, 1, status.Volumes[1].ID)
+	require.EqualValues(t, 900, status.Volumes[1].Size)
+	require.Equal(t, []string{"10.0.0.1:8080", "10.0.0.2:8080"}, status.Volumes[1].Servers)
+
+	require.EqualValues(t, 2, status.Volumes[2].ID)
+	require.True(t, status.Volumes[2].ReadOnly)
+	require.Zero(t, status.Volumes[2].Ratio)
+
+	require.EqualValues(t, 8900, status.Size)
+	require.EqualValues(t, 1700, status.DeletedByteCount)
+	require.InDelta(t, 1700.0/8900, status.Ratio(), 1e-9)
+
+	require.Len(t, status.Above(0.3), 1)
+	require.Len(t, status.Above(0.2), 2)
+	require.Empty(t, (&GarbageStatus{}).Above(0))
+}
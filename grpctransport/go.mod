@@ -0,0 +1,15 @@
+module github.com/ocean2811/goseaweedfs/grpctransport
+
+go 1.16
+
+require (
+	github.com/ocean2811/goseaweedfs v0.0.0
+	github.com/stretchr/testify v1.7.0
+	google.golang.org/grpc v1.35.0
+)
+
+// SeaweedFS tags releases without the v prefix, so its protos are pinned to the pseudo-version of the release
+// of the cluster, added by: go get github.com/seaweedfs/seaweedfs@<release>
+
+// built against the library of the same checkout
+replace github.com/ocean2811/goseaweedfs => ../
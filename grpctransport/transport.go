@@ -0,0 +1,307 @@
+// Package grpctransport carries goseaweedfs metadata operations (assign, lookup, filer listing and metadata
+// subscription) over SeaweedFS's gRPC API, built on its published protos. It is a separate module, so the library
+// does not depend on gRPC. Configure it with goseaweedfs.WithTransport:
+//
+//	t, err := grpctransport.New("localhost:9333", grpc.WithInsecure())
+//	sw, err := goseaweedfs.NewSeaweed("http://localhost:9333", filers, chunkSize, client, goseaweedfs.WithTransport(t))
+package grpctransport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ocean2811/goseaweedfs"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"google.golang.org/grpc"
+)
+
+// DefaultListLimit is the page size of listing when no limit is given.
+const DefaultListLimit = 1024
+
+// ClientName is reported to filer when subscribing to metadata changes.
+const ClientName = "goseaweedfs"
+
+// Transport implements goseaweedfs.Transport over gRPC. Master is dialed by New, filers are dialed on first use.
+type Transport struct {
+	conn   *grpc.ClientConn
+	master master_pb.SeaweedClient
+	opts   []grpc.DialOption
+
+	mu     sync.Mutex
+	filers map[string]*grpc.ClientConn
+}
+
+var _ goseaweedfs.Transport = (*Transport)(nil)
+
+// New dials master, given as host:port of its HTTP endpoint (see GRPCAddress). Dial options apply to filers too.
+func New(master string, opts ...grpc.DialOption) (t *Transport, err error) {
+	conn, err := grpc.Dial(GRPCAddress(master), opts...)
+	if err != nil {
+		return
+	}
+
+	t = &Transport{
+		conn:   conn,
+		master: master_pb.NewSeaweedClient(conn),
+		opts:   opts,
+		filers: make(map[string]*grpc.ClientConn),
+	}
+	return
+}
+
+// GRPCAddress returns gRPC endpoint of a server given host:port of its HTTP endpoint. By SeaweedFS convention,
+// gRPC listens on HTTP port + 10000, unless given explicitly in SeaweedFS's host:port.grpcPort form.
+func GRPCAddress(hostPort string) string {
+	if i := strings.LastIndex(hostPort, "."); i > strings.LastIndex(hostPort, ":") && strings.Contains(hostPort, ":") {
+		host, _, _ := net.SplitHostPort(hostPort[:i])
+		return net.JoinHostPort(host, hostPort[i+1:])
+	}
+
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return hostPort
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return hostPort
+	}
+	return net.JoinHostPort(host, strconv.Itoa(p+10000))
+}
+
+// Close connections to master and filers.
+func (t *Transport) Close() (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for hostPort, conn := range t.filers {
+		if e := conn.Close(); err == nil {
+			err = e
+		}
+		delete(t.filers, hostPort)
+	}
+	if e := t.conn.Close(); err == nil {
+		err = e
+	}
+	return
+}
+
+func (t *Transport) filer(hostPort string) (client filer_pb.SeaweedFilerClient, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	conn, ok := t.filers[hostPort]
+	if !ok {
+		if conn, err = grpc.Dial(GRPCAddress(hostPort), t.opts...); err != nil {
+			return
+		}
+		t.filers[hostPort] = conn
+	}
+	return filer_pb.NewSeaweedFilerClient(conn), nil
+}
+
+// Lookup volume locations from master.
+func (t *Transport) Lookup(ctx context.Context, volumeID string, args url.Values) (goseaweedfs.VolumeLocations, error) {
+	resp, err := t.master.LookupVolume(ctx, &master_pb.LookupVolumeRequest{
+		VolumeOrFileIds: []string{volumeID},
+		Collection:      args.Get(goseaweedfs.ParamLookupCollection),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vl := range resp.GetVolumeIdLocations() {
+		if vl.GetError() != "" {
+			return nil, errors.New(vl.GetError())
+		}
+		return volumeLocations(vl.GetLocations()), nil
+	}
+	return nil, nil
+}
+
+// Assign file ids from master.
+func (t *Transport) Assign(ctx context.Context, args url.Values) (result *goseaweedfs.AssignResult, err error) {
+	count, _ := strconv.ParseUint(args.Get(goseaweedfs.ParamAssignCount), 10, 64)
+	if count == 0 {
+		count = 1
+	}
+
+	resp, err := t.master.Assign(ctx, &master_pb.AssignRequest{
+		Count:       count,
+		Collection:  args.Get(goseaweedfs.ParamCollection),
+		Ttl:         args.Get(goseaweedfs.ParamTTL),
+		Replication: args.Get(goseaweedfs.ParamAssignReplication),
+		DataCenter:  args.Get(goseaweedfs.ParamAssignDataCenter),
+	})
+	if err != nil {
+		return
+	}
+
+	result = &goseaweedfs.AssignResult{
+		FileID:   resp.GetFid(),
+		Count:    resp.GetCount(),
+		Error:    resp.GetError(),
+		Replicas: volumeLocations(resp.GetReplicas()),
+	}
+	if loc := resp.GetLocation(); loc != nil {
+		result.URL, result.PublicURL = loc.GetUrl(), loc.GetPublicUrl()
+	}
+	return
+}
+
+// ListDir lists a page of entries of dir on filer.
+func (t *Transport) ListDir(ctx context.Context, filer, dir, lastFileName string, limit int) (listing *goseaweedfs.FilerListing, err error) {
+	client, err := t.filer(filer)
+	if err != nil {
+		return
+	}
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+
+	stream, err := client.ListEntries(ctx, &filer_pb.ListEntriesRequest{
+		Directory:         dir,
+		StartFromFileName: lastFileName,
+		Limit:             uint32(limit),
+	})
+	if err != nil {
+		return
+	}
+
+	page := &goseaweedfs.FilerListing{Path: dir, Limit: limit}
+	for {
+		var resp *filer_pb.ListEntriesResponse
+		if resp, err = stream.Recv(); err == io.EOF {
+			break
+		} else if err != nil {
+			return
+		}
+		page.Entries = append(page.Entries, fileInfo(dir, resp.GetEntry()))
+	}
+
+	if n := len(page.Entries); n > 0 {
+		page.LastFileName = page.Entries[n-1].Name()
+		page.ShouldDisplayLoadMore = n == limit
+	} else if lastFileName == "" && dir != "/" {
+		// listing a missing directory is not an error over gRPC, tell it apart from an empty one
+		if _, err = client.LookupDirectoryEntry(ctx, &filer_pb.LookupDirectoryEntryRequest{
+			Directory: path.Dir(dir),
+			Name:      path.Base(dir),
+		}); err != nil {
+			if strings.Contains(err.Error(), filer_pb.ErrNotFound.Error()) {
+				err = goseaweedfs.ErrFileNotFound
+			}
+			return
+		}
+	}
+	return page, nil
+}
+
+// SubscribeMetadata streams metadata changes under pathPrefix on filer.
+func (t *Transport) SubscribeMetadata(ctx context.Context, filer, pathPrefix string, since time.Time, fn goseaweedfs.MetadataEventFunc) error {
+	client, err := t.filer(filer)
+	if err != nil {
+		return err
+	}
+
+	var sinceNs int64
+	if !since.IsZero() {
+		sinceNs = since.UnixNano()
+	}
+
+	stream, err := client.SubscribeMetadata(ctx, &filer_pb.SubscribeMetadataRequest{
+		ClientName: ClientName,
+		PathPrefix: pathPrefix,
+		SinceNs:    sinceNs,
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		if err = fn(metadataEvent(resp)); err != nil {
+			return err
+		}
+	}
+}
+
+func metadataEvent(resp *filer_pb.SubscribeMetadataResponse) *goseaweedfs.MetadataEvent {
+	n := resp.GetEventNotification()
+	e := &goseaweedfs.MetadataEvent{
+		Directory:     resp.GetDirectory(),
+		NewParentPath: n.GetNewParentPath(),
+		Time:          time.Unix(0, resp.GetTsNs()),
+	}
+
+	if entry := n.GetOldEntry(); entry != nil {
+		e.OldEntry = fileInfo(e.Directory, entry)
+	}
+	if entry := n.GetNewEntry(); entry != nil {
+		dir := e.NewParentPath
+		if dir == "" {
+			dir = e.Directory
+		}
+		e.NewEntry = fileInfo(dir, entry)
+	}
+	return e
+}
+
+// fileInfo converts filer entry in dir, as HTTP filer would report it with metadata=true.
+func fileInfo(dir string, entry *filer_pb.Entry) *goseaweedfs.FileInfo {
+	attr := entry.GetAttributes()
+	fi := &goseaweedfs.FileInfo{
+		FullPath: path.Join(dir, entry.GetName()),
+		Mtime:    time.Unix(attr.GetMtime(), 0),
+		Crtime:   time.Unix(attr.GetCrtime(), 0),
+		FileMode: os.FileMode(attr.GetFileMode()),
+		Mime:     attr.GetMime(),
+		TTLSec:   attr.GetTtlSec(),
+		FileSize: attr.GetFileSize(),
+		Md5:      attr.GetMd5(),
+		Extended: entry.GetExtended(),
+		Quota:    entry.GetQuota(),
+		Content:  entry.GetContent(),
+	}
+	if entry.GetIsDirectory() {
+		fi.FileMode |= os.ModeDir
+	}
+
+	for _, c := range entry.GetChunks() {
+		fi.Chunks = append(fi.Chunks, &goseaweedfs.FileChunk{
+			FileID: c.GetFileId(),
+			Offset: c.GetOffset(),
+			Size:   c.GetSize(),
+			Mtime:  c.GetModifiedTsNs(),
+			ETag:   c.GetETag(),
+		})
+	}
+	return fi
+}
+
+func volumeLocations(locations []*master_pb.Location) (result goseaweedfs.VolumeLocations) {
+	for _, loc := range locations {
+		result = append(result, &goseaweedfs.VolumeLocation{
+			URL:        loc.GetUrl(),
+			PublicURL:  loc.GetPublicUrl(),
+			DataCenter: loc.GetDataCenter(),
+		})
+	}
+	return
+}
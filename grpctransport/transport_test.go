@@ -0,0 +1,41 @@
+package grpctransport
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGRPCAddress(t *testing.T) {
+	require.Equal(t, "localhost:19333", GRPCAddress("localhost:9333"))
+	require.Equal(t, "10.0.0.1:18888", GRPCAddress("10.0.0.1:8888"))
+	require.Equal(t, "10.0.0.1:28888", GRPCAddress("10.0.0.1:8888.28888"))
+	require.Equal(t, "localhost", GRPCAddress("localhost"))
+}
+
+func TestMetadataEvent(t *testing.T) {
+	e := metadataEvent(&filer_pb.SubscribeMetadataResponse{
+		Directory: "/logs",
+		TsNs:      int64(time.Second),
+		EventNotification: &filer_pb.EventNotification{
+			OldEntry:      &filer_pb.Entry{Name: "a.txt"},
+			NewEntry:      &filer_pb.Entry{Name: "sub", IsDirectory: true, Attributes: &filer_pb.FuseAttributes{FileMode: 0755}},
+			NewParentPath: "/archive",
+		},
+	})
+
+	require.Equal(t, "/logs/a.txt", e.OldEntry.FullPath)
+	require.Equal(t, "/archive/sub", e.NewEntry.FullPath)
+	require.Equal(t, os.ModeDir|0755, e.NewEntry.FileMode)
+	require.Equal(t, time.Unix(1, 0), e.Time)
+
+	e = metadataEvent(&filer_pb.SubscribeMetadataResponse{
+		Directory:         "/logs",
+		EventNotification: &filer_pb.EventNotification{NewEntry: &filer_pb.Entry{Name: "b.txt"}},
+	})
+	require.Nil(t, e.OldEntry)
+	require.Equal(t, "/logs/b.txt", e.NewEntry.FullPath)
+}
@@ -0,0 +1,100 @@
+package goseaweedfs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrUnhealthy return when pinged endpoint is not healthy.
+var ErrUnhealthy = fmt.Errorf("Endpoint is unhealthy")
+
+// Health of a master/filer endpoint, suitable for readiness checks.
+type Health struct {
+	URL        string
+	Reachable  bool
+	StatusCode int
+	Latency    time.Duration
+
+	// IsLeader and Leader are reported by master only.
+	IsLeader bool
+	Leader   string
+
+	// FreeVolumes and MaxVolumes are reported by master only.
+	FreeVolumes int
+	MaxVolumes  int
+
+	Error string
+}
+
+// Err returns ErrUnhealthy with detail if endpoint is not healthy, nil otherwise.
+func (h *Health) Err() error {
+	if h.Error == "" {
+		return nil
+	}
+	return fmt.Errorf("%w: %s %s", ErrUnhealthy, h.URL, h.Error)
+}
+
+func (h *Health) check(err error) {
+	h.Reachable = h.StatusCode != 0
+	h.Error = endpointProblem(h.StatusCode, err)
+}
+
+// endpointProblem classifies response of a master/filer endpoint, shared by Ping and Validate so they never
+// disagree. Returns empty string if endpoint responded fine.
+func endpointProblem(statusCode int, err error) string {
+	switch {
+	case statusCode == 0:
+		return fmt.Sprintf("unreachable: %v", err)
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return fmt.Sprintf("unauthorized, status code %d", statusCode)
+	case statusCode >= http.StatusBadRequest:
+		return fmt.Sprintf("status code %d", statusCode)
+	case err != nil:
+		return fmt.Sprintf("invalid response: %v", err)
+	}
+	return ""
+}
+
+// Ping checks health of master, reporting leadership and free volumes of cluster.
+// Returned error wraps ErrUnhealthy if master is unreachable, has no leader or responds with error.
+func (c *Seaweed) Ping(ctx context.Context) (h *Health, err error) {
+	master := c.masterURL()
+	h = &Health{URL: master.String()}
+
+	start := time.Now()
+	cluster := &ClusterStatus{}
+	h.StatusCode, err = c.client.getJSONContext(ctx, encodeURI(master, "/cluster/status", nil), nil, cluster)
+	h.Latency = time.Since(start)
+	if h.check(err); h.Error == "" {
+		h.IsLeader, h.Leader = cluster.IsLeader, cluster.Leader
+		if h.Leader == "" {
+			h.Error = "no leader elected"
+		}
+	}
+
+	if h.Error == "" {
+		status := &SystemStatus{}
+		h.StatusCode, err = c.client.getJSONContext(ctx, encodeURI(master, "/dir/status", nil), nil, status)
+		if h.check(err); h.Error == "" {
+			h.FreeVolumes, h.MaxVolumes = status.Topology.Free, status.Topology.Max
+		}
+	}
+
+	err = h.Err()
+	return
+}
+
+// Ping checks health of filer. Returned error wraps ErrUnhealthy if filer is unreachable or responds with error.
+func (f *Filer) Ping(ctx context.Context) (h *Health, err error) {
+	h = &Health{URL: f.base.String()}
+
+	start := time.Now()
+	h.StatusCode, _, err = f.client.probe(ctx, http.MethodHead, encodeURI(*f.base, "/", nil))
+	h.Latency = time.Since(start)
+	h.check(err)
+
+	err = h.Err()
+	return
+}
@@ -0,0 +1,76 @@
+package goseaweedfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPing(t *testing.T) {
+	leader := "localhost:9333"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cluster/status":
+			fmt.Fprintf(w, `{"IsLeader":true,"Leader":%q}`, leader)
+		case "/dir/status":
+			fmt.Fprint(w, `{"Topology":{"Free":3,"Max":10}}`)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	sw, err := NewSeaweed(server.URL, []string{server.URL}, 1024, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	h, err := sw.Ping(context.Background())
+	require.Nil(t, err)
+	require.True(t, h.Reachable)
+	require.True(t, h.IsLeader)
+	require.Equal(t, 3, h.FreeVolumes)
+	require.Equal(t, 10, h.MaxVolumes)
+
+	h, err = sw.Filers()[0].Ping(context.Background())
+	require.Nil(t, err)
+	require.True(t, h.Reachable)
+
+	leader = ""
+	h, err = sw.Ping(context.Background())
+	require.True(t, errors.Is(err, ErrUnhealthy))
+	require.True(t, h.Reachable)
+
+	server.Close()
+	h, err = sw.Filers()[0].Ping(context.Background())
+	require.True(t, errors.Is(err, ErrUnhealthy))
+	require.False(t, h.Reachable)
+}
+
+func TestEndpointProblemShared(t *testing.T) {
+	for _, c := range []struct {
+		statusCode int
+		err        error
+		problem    string
+	}{
+		{0, fmt.Errorf("connection refused"), "unreachable: connection refused"},
+		{http.StatusForbidden, nil, "unauthorized, status code 403"},
+		{http.StatusNotFound, nil, "status code 404"},
+		{http.StatusBadGateway, nil, "status code 502"},
+		{http.StatusOK, fmt.Errorf("EOF"), "invalid response: EOF"},
+		{http.StatusOK, nil, ""},
+	} {
+		h := &Health{StatusCode: c.statusCode}
+		h.check(c.err)
+		r := &EndpointReport{StatusCode: c.statusCode}
+		checkEndpoint(r, "", c.err)
+
+		require.Equal(t, c.problem, h.Error)
+		require.Equal(t, h.Error, r.Error)
+		require.Equal(t, h.Reachable, r.Reachable)
+	}
+}
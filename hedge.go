@@ -0,0 +1,149 @@
+package goseaweedfs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// hedgeSamples is the number of recent latencies used to estimate hedging delay.
+const hedgeSamples = 256
+
+// hedger tracks recent latencies of read requests and decides when to send a hedged (duplicated) request.
+type hedger struct {
+	percentile float64
+	minDelay   time.Duration
+
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func newHedger(percentile float64, minDelay time.Duration) *hedger {
+	if percentile > 1 {
+		percentile = 1
+	}
+	return &hedger{
+		percentile: percentile,
+		minDelay:   minDelay,
+		samples:    make([]time.Duration, 0, hedgeSamples),
+	}
+}
+
+func (h *hedger) observe(d time.Duration) {
+	h.mu.Lock()
+	if len(h.samples) < hedgeSamples {
+		h.samples = append(h.samples, d)
+	} else {
+		h.samples[h.next] = d
+		h.next = (h.next + 1) % hedgeSamples
+	}
+	h.mu.Unlock()
+}
+
+// delay returns configured percentile of recent latencies, at least minDelay.
+func (h *hedger) delay() time.Duration {
+	h.mu.Lock()
+	sorted := append([]time.Duration(nil), h.samples...)
+	h.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return h.minDelay
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	d := sorted[int(h.percentile*float64(len(sorted)-1))]
+	if d < h.minDelay {
+		d = h.minDelay
+	}
+	return d
+}
+
+type attemptResult struct {
+	attempt int
+	value   interface{}
+	err     error
+}
+
+// race runs call for attempt 0, then starts next attempt whenever previous one failed or hedging delay elapsed
+// (h might be nil to disable hedging), until an attempt succeeds or all attempts failed. Attempts are canceled
+// along with parent.
+// Winner's context is handed over to it through its value, by calling keep(value, cancel); values of losers are released by discard.
+func (h *hedger) race(parent context.Context, attempts int, call func(ctx context.Context, attempt int) (interface{}, error),
+	keep func(value interface{}, cancel context.CancelFunc), discard func(value interface{})) (value interface{}, err error) {
+	results := make(chan attemptResult, attempts)
+	cancels := make([]context.CancelFunc, 0, attempts)
+
+	launched := 0
+	launch := func() {
+		ctx, cancel := context.WithCancel(parent)
+		cancels = append(cancels, cancel)
+
+		attempt := launched
+		launched++
+
+		go func() {
+			start := time.Now()
+			v, e := call(ctx, attempt)
+			if e == nil && h != nil {
+				h.observe(time.Since(start))
+			}
+			results <- attemptResult{attempt: attempt, value: v, err: e}
+		}()
+	}
+
+	// timer is disarmed once every attempt is launched, so a pending hedge never starts one too many
+	var timer <-chan time.Time
+	resetTimer := func() {
+		timer = nil
+		if h != nil && launched < attempts {
+			timer = time.After(h.delay())
+		}
+	}
+
+	launch()
+	resetTimer()
+
+	for pending := 1; pending > 0; {
+		select {
+		case r := <-results:
+			pending--
+
+			if r.err == nil {
+				// stop and release losers
+				for i, cancel := range cancels {
+					if i != r.attempt {
+						cancel()
+					}
+				}
+				go func(pending int) {
+					for ; pending > 0; pending-- {
+						if loser := <-results; loser.err == nil {
+							discard(loser.value)
+						}
+					}
+				}(pending)
+
+				keep(r.value, cancels[r.attempt])
+				return r.value, nil
+			}
+
+			cancels[r.attempt]()
+			err = r.err
+
+			if launched < attempts {
+				launch()
+				pending++
+				resetTimer()
+			}
+
+		case <-timer:
+			launch()
+			pending++
+			resetTimer()
+		}
+	}
+
+	return
+}
@@ -0,0 +1,122 @@
+package goseaweedfs
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedgerDelay(t *testing.T) {
+	h := newHedger(0.9, time.Millisecond)
+	require.Equal(t, time.Millisecond, h.delay())
+
+	for i := 1; i <= 100; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	require.Equal(t, 90*time.Millisecond, h.delay())
+
+	// old samples are overwritten
+	for i := 0; i < hedgeSamples; i++ {
+		h.observe(0)
+	}
+	require.Equal(t, time.Millisecond, h.delay())
+}
+
+func TestHedgerRace(t *testing.T) {
+	noop := func(interface{}, context.CancelFunc) {}
+	discard := func(interface{}) {}
+
+	// slow first attempt is hedged by second one
+	h := newHedger(0.5, 10*time.Millisecond)
+	var canceled int32
+	v, err := h.race(context.Background(), 2, func(ctx context.Context, attempt int) (interface{}, error) {
+		if attempt == 0 {
+			<-ctx.Done()
+			atomic.AddInt32(&canceled, 1)
+			return nil, ctx.Err()
+		}
+		return attempt, nil
+	}, noop, discard)
+	require.Nil(t, err)
+	require.Equal(t, 1, v)
+
+	// without hedging, next attempt only starts after failure
+	var nilHedger *hedger
+	var calls int32
+	v, err = nilHedger.race(context.Background(), 3, func(ctx context.Context, attempt int) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		if attempt < 1 {
+			return nil, fmt.Errorf("Fake error")
+		}
+		return attempt, nil
+	}, noop, discard)
+	require.Nil(t, err)
+	require.Equal(t, 1, v)
+	require.EqualValues(t, 2, calls)
+
+	// all attempts failed
+	_, err = nilHedger.race(context.Background(), 2, func(ctx context.Context, attempt int) (interface{}, error) {
+		return nil, fmt.Errorf("Fake error %d", attempt)
+	}, noop, discard)
+	require.EqualError(t, err, "Fake error 1")
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&canceled) == 1 }, time.Second, time.Millisecond)
+}
+
+func TestHedgedReadFirstReplicaFailsFast(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprint(w, "content")
+	}))
+	defer slow.Close()
+
+	failingAddr, slowAddr := failing.Listener.Addr().String(), slow.Listener.Addr().String()
+	sw, err := NewSeaweed("http://127.0.0.1:1", nil, 1024, slow.Client(), WithHedging(0.5, 10*time.Millisecond),
+		WithLookuper(LookuperFunc(func(context.Context, string, url.Values) (VolumeLocations, error) {
+			return VolumeLocations{{URL: failingAddr, PublicURL: failingAddr}, {URL: slowAddr, PublicURL: slowAddr}}, nil
+		})))
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	// whichever replica is tried first, hedge timer armed before the fast failure must not start a third attempt
+	for i := 0; i < 10; i++ {
+		r, err := sw.Fetch("3,01637037d6", nil, nil)
+		require.Nil(t, err)
+		data, _ := ioutil.ReadAll(r.Body)
+		require.Nil(t, r.Close())
+		require.Equal(t, "content", string(data))
+	}
+}
+
+func TestHedgerRaceAttemptBound(t *testing.T) {
+	h := newHedger(0.5, 10*time.Millisecond)
+	var max int32
+	v, err := h.race(context.Background(), 2, func(ctx context.Context, attempt int) (interface{}, error) {
+		for {
+			m := atomic.LoadInt32(&max)
+			if int32(attempt) <= m || atomic.CompareAndSwapInt32(&max, m, int32(attempt)) {
+				break
+			}
+		}
+		if attempt == 0 {
+			return nil, fmt.Errorf("Fake error")
+		}
+		time.Sleep(50 * time.Millisecond)
+		return attempt, nil
+	}, func(interface{}, context.CancelFunc) {}, func(interface{}) {})
+	require.Nil(t, err)
+	require.Equal(t, 1, v)
+	require.EqualValues(t, 1, atomic.LoadInt32(&max))
+}
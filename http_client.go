@@ -5,36 +5,114 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
-	"path/filepath"
-	"strconv"
-	"strings"
+	"sync"
+	"time"
 
 	workerpool "github.com/linxGnu/gumble/worker-pool"
 )
 
 type httpClient struct {
-	client  *http.Client
-	workers *workerpool.Pool
+	client   *http.Client
+	workers  *workerpool.Pool
+	opts     *options
+	breakers *breakers
+	balancer *balancer
+
+	// redirectClient shares transport with client, but lets redirects be followed by policy.
+	redirectClient *http.Client
+
+	// retryBuffers holds buffers of retryBufferSize+1 bytes for non-seekable upload content, see rewindable.
+	retryBuffers sync.Pool
+
+	closeOnce sync.Once
 }
 
-func newHTTPClient(client *http.Client) *httpClient {
+func newHTTPClient(client *http.Client, opts *options) *httpClient {
 	c := &httpClient{
 		client:  client,
 		workers: createWorkerPool(),
+		opts:    opts,
+	}
+	if opts.breakerThreshold > 0 {
+		c.breakers = newBreakers(opts.breakerThreshold, opts.breakerCooldown, opts.breakerHook)
+	}
+	if opts.leastLoaded {
+		c.balancer = newBalancer()
+	}
+	if opts.redirectPolicy != nil {
+		c.redirectClient = noFollow(client)
+	}
+	c.retryBuffers.New = func() interface{} {
+		buf := make([]byte, opts.retryBufferSize+1)
+		return &buf
 	}
 	c.workers.Start()
 	return c
 }
 
 func (c *httpClient) Close() (err error) {
-	c.workers.Stop()
+	c.closeOnce.Do(c.workers.Stop)
 	return
 }
 
+// do sends request, applying client-wide policies (e.g. identity headers, circuit breaker, load tracking) around it.
+func (c *httpClient) do(req *http.Request) (resp *http.Response, err error) {
+	return c.doWith(c.client, req)
+}
+
+func (c *httpClient) doWith(client *http.Client, req *http.Request) (resp *http.Response, err error) {
+	c.setIdentity(req)
+
+	host := req.URL.Host
+	if c.breakers != nil {
+		if err = c.breakers.allow(host); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, err)
+		}
+	}
+
+	var canceled bool
+	if c.balancer != nil {
+		done := c.balancer.start(host)
+		defer func() { done(resp, err, canceled) }()
+	}
+
+	resp, err = client.Do(req)
+	canceled = canceledByCaller(req, err)
+	if c.breakers != nil {
+		if canceled {
+			c.breakers.release(host)
+		} else {
+			c.breakers.record(host, !isFailure(resp, err))
+		}
+	}
+	return
+}
+
+// pickUploadTarget chooses upload target among writable locations of assigned volume.
+func (c *httpClient) pickUploadTarget(res *AssignResult) string {
+	if c.balancer == nil || len(res.Replicas) == 0 {
+		return res.URL
+	}
+
+	hosts := []string{res.URL}
+	for _, r := range res.Replicas {
+		if r.URL != "" && r.URL != res.URL {
+			hosts = append(hosts, r.URL)
+		}
+	}
+	return c.balancer.pick(hosts)
+}
+
+func (c *httpClient) breakerStates() map[string]BreakerState {
+	if c.breakers == nil {
+		return nil
+	}
+	return c.breakers.states()
+}
+
 func (c *httpClient) get(url string, header map[string]string) (body []byte, statusCode int, err error) {
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err == nil {
@@ -43,27 +121,63 @@ func (c *httpClient) get(url string, header map[string]string) (body []byte, sta
 		}
 
 		var resp *http.Response
-		resp, err = c.client.Do(req)
+		resp, err = c.do(req)
+		if err == nil {
+			body, statusCode, err = readAll(resp, c.opts.maxResponseSize)
+		}
+	}
+
+	return
+}
+
+func (c *httpClient) getJSON(url string, header map[string]string, out interface{}) (statusCode int, err error) {
+	return c.getJSONContext(context.Background(), url, header, out)
+}
+
+func (c *httpClient) getJSONContext(ctx context.Context, url string, header map[string]string, out interface{}) (statusCode int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err == nil {
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+
+		var resp *http.Response
+		resp, err = c.do(req)
 		if err == nil {
-			body, statusCode, err = readAll(resp)
+			statusCode, err = decodeJSON(resp, c.opts.maxResponseSize, out)
 		}
 	}
 
 	return
 }
 
+// dryRun reports whether a destructive request should be skipped, notifying dry run hook.
+func (c *httpClient) dryRun(method, url string) bool {
+	if !c.opts.dryRun {
+		return false
+	}
+	if c.opts.dryRunHook != nil {
+		c.opts.dryRunHook(method, url)
+	}
+	return true
+}
+
 func (c *httpClient) delete(url string) (statusCode int, err error) {
+	if c.dryRun(http.MethodDelete, url) {
+		return http.StatusOK, nil
+	}
+
 	req, err := http.NewRequest(http.MethodDelete, url, nil)
 	if err != nil {
 		return
 	}
 
-	r, err := c.client.Do(req)
+	r, err := c.do(req)
 	if err != nil {
 		return
 	}
 
-	body, statusCode, err := readAll(r)
+	body, statusCode, err := readAll(r, c.opts.maxResponseSize)
 	if err == nil {
 		switch r.StatusCode {
 		case http.StatusNoContent, http.StatusNotFound, http.StatusAccepted, http.StatusOK:
@@ -71,328 +185,302 @@ func (c *httpClient) delete(url string) (statusCode int, err error) {
 			return
 		}
 
-		m := make(map[string]interface{})
-		if e := json.Unmarshal(body, &m); e == nil {
-			if s, ok := m["error"].(string); ok {
-				err = fmt.Errorf("Delete %s: %v", url, s)
-				return
-			}
-		}
-
-		err = fmt.Errorf("Delete %s. Got response but can not parse. Body:%s Code:%d", url, string(body), r.StatusCode)
+		err = responseError("Delete", url, body, statusCode)
 	}
 
 	return
 }
 
-func (c *httpClient) preview(url string) (filename string, size int64, md map[string]string, err error) {
-	r, err := c.client.Head(url)
-	if err == nil {
-		if r.StatusCode != http.StatusOK {
-			drainAndClose(r.Body)
-			err = fmt.Errorf("Preview %s but error. Status:%s", url, r.Status)
-
-			if r.StatusCode == http.StatusNotFound {
-				err = fmt.Errorf("%w: %v", ErrFileNotFound, err)
-			}
-			return
-		}
+// post sends a body-less POST request, which is used by filer for operations like mkdir and move.
+func (c *httpClient) post(url string) (statusCode int, err error) {
+	if c.dryRun(http.MethodPost, url) {
+		return http.StatusOK, nil
+	}
 
-		contentDisposition := r.Header["Content-Disposition"]
-		if len(contentDisposition) > 0 {
-			if i := strings.Index(contentDisposition[0], "filename="); i >= 0 {
-				filename = contentDisposition[0][i+len("filename="):]
-				filename = strings.Trim(filename, "\"")
-			}
-		}
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return
+	}
 
-		contentLength := r.Header["Content-Length"]
-		if len(contentLength) > 0 {
-			size, _ = strconv.ParseInt(contentLength[0], 10, 64)
-		}
+	r, err := c.do(req)
+	if err != nil {
+		return
+	}
 
-		md = make(map[string]string, len(r.Header))
-		for k, v := range r.Header {
-			if len(v) == 0 {
-				continue
-			}
-			md[k] = v[0]
+	body, statusCode, err := readAll(r, c.opts.maxResponseSize)
+	if err == nil && statusCode >= http.StatusMultipleChoices {
+		err = responseError("Post", url, body, statusCode)
+		if statusCode == http.StatusNotFound {
+			err = fmt.Errorf("%w: %v", ErrFileNotFound, err)
 		}
-
-		// drain and close body
-		drainAndClose(r.Body)
 	}
 
 	return
 }
 
-func (c *httpClient) downloadWithMetadata(url string, callback func(io.Reader) error) (filename string, md map[string]string, err error) {
-	r, err := c.client.Get(url)
-	if err == nil {
-		if r.StatusCode != http.StatusOK {
-			drainAndClose(r.Body)
-			err = fmt.Errorf("Download %s but error. Status:%s", url, r.Status)
-
-			if r.StatusCode == http.StatusNotFound {
-				err = fmt.Errorf("%w: %v", ErrFileNotFound, err)
-			}
-			return
-		}
+func (c *httpClient) put(url string, header http.Header) (statusCode int, err error) {
+	if c.dryRun(http.MethodPut, url) {
+		return http.StatusOK, nil
+	}
 
-		contentDisposition := r.Header["Content-Disposition"]
-		if len(contentDisposition) > 0 {
-			if i := strings.Index(contentDisposition[0], "filename="); i >= 0 {
-				filename = contentDisposition[0][i+len("filename="):]
-				filename = strings.Trim(filename, "\"")
-			}
-		}
-		md = make(map[string]string, len(r.Header))
-		for k, v := range r.Header {
-			if len(v) == 0 {
-				continue
-			}
-			md[k] = v[0]
-		}
+	req, err := http.NewRequest(http.MethodPut, url, nil)
+	if err != nil {
+		return
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
 
-		// execute callback
-		err = callback(r.Body)
+	r, err := c.do(req)
+	if err != nil {
+		return
+	}
 
-		// drain and close body
-		drainAndClose(r.Body)
+	body, statusCode, err := readAll(r, c.opts.maxResponseSize)
+	if err == nil && statusCode >= http.StatusMultipleChoices {
+		err = responseError("Put", url, body, statusCode)
+		if statusCode == http.StatusNotFound {
+			err = fmt.Errorf("%w: %v", ErrFileNotFound, err)
+		}
 	}
 
 	return
 }
 
-func (c *httpClient) download(url string, callback func(io.Reader) error) (filename string, err error) {
-	r, err := c.client.Get(url)
-	if err == nil {
-		if r.StatusCode != http.StatusOK {
-			drainAndClose(r.Body)
-			err = fmt.Errorf("Download %s but error. Status:%s", url, r.Status)
-
-			if r.StatusCode == http.StatusNotFound {
-				err = fmt.Errorf("%w: %v", ErrFileNotFound, err)
-			}
-			return
-		}
-
-		contentDisposition := r.Header["Content-Disposition"]
-		if len(contentDisposition) > 0 {
-			if i := strings.Index(contentDisposition[0], "filename="); i >= 0 {
-				filename = contentDisposition[0][i+len("filename="):]
-				filename = strings.Trim(filename, "\"")
-			}
+// responseError extracts error message from response body if possible.
+func responseError(op, url string, body []byte, statusCode int) error {
+	m := make(map[string]interface{})
+	if e := json.Unmarshal(body, &m); e == nil {
+		if s, ok := m["error"].(string); ok {
+			return fmt.Errorf("%s %s: %v", op, url, s)
 		}
+	}
 
-		// execute callback
-		err = callback(r.Body)
+	return fmt.Errorf("%s %s. Got response but can not parse. Body:%s Code:%d", op, url, string(body), statusCode)
+}
 
-		// drain and close body
-		drainAndClose(r.Body)
+func (c *httpClient) probe(ctx context.Context, method, url string) (statusCode int, header http.Header, err error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err == nil {
+		var resp *http.Response
+		if resp, err = c.do(req); err == nil {
+			statusCode, header = resp.StatusCode, resp.Header
+			drainAndClose(resp.Body)
+		}
 	}
-
 	return
 }
 
-func (c *httpClient) downloadByReadCloserWithHeader(url string, rqHeader http.Header) (filename string, size int64, rsHeader http.Header, rsBody io.ReadCloser, err error) {
-	req, err := http.NewRequest("GET", url, nil)
+func (c *httpClient) fetch(method, url string, header http.Header) (result *DownloadResult, err error) {
+	return c.fetchContext(context.Background(), method, url, header)
+}
+
+func (c *httpClient) fetchContext(ctx context.Context, method, url string, header http.Header) (result *DownloadResult, err error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
-		return filename, size, rsHeader, rsBody, err
+		return
 	}
-	for k, vs := range rqHeader {
-		if len(req.Header.Get(k)) == 0 {
-			for _, v := range vs {
-				req.Header.Add(k, v)
-			}
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
 		}
 	}
 
-	rs, err := c.client.Do(req)
+	r, err := c.read(req)
 	if err != nil {
-		return filename, size, rsHeader, rsBody, err
+		return
 	}
 
-	if err == nil {
-		if rs.StatusCode != http.StatusOK && rs.StatusCode != http.StatusPartialContent {
-			drainAndClose(rs.Body)
-			err = fmt.Errorf("Download %s but error. Status:%s", url, rs.Status)
-
-			if rs.StatusCode == http.StatusNotFound {
-				err = fmt.Errorf("%w: %v", ErrFileNotFound, err)
-			}
-			return
-		}
+	if r.StatusCode != http.StatusOK && r.StatusCode != http.StatusPartialContent {
+		drainAndClose(r.Body)
 
-		contentDisposition := rs.Header["Content-Disposition"]
-		if len(contentDisposition) > 0 {
-			if i := strings.Index(contentDisposition[0], "filename="); i >= 0 {
-				filename = contentDisposition[0][i+len("filename="):]
-				filename = strings.Trim(filename, "\"")
-			}
+		op := "Download"
+		if method == http.MethodHead {
+			op = "Preview"
 		}
+		err = fmt.Errorf("%s %s but error. Status:%s", op, url, r.Status)
 
-		contentLength := rs.Header["Content-Length"]
-		if len(contentLength) > 0 {
-			size, _ = strconv.ParseInt(contentLength[0], 10, 64)
+		if r.StatusCode == http.StatusNotFound {
+			err = fmt.Errorf("%w: %v", ErrFileNotFound, err)
+		} else if r.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			err = fmt.Errorf("%w: %v", errRangeNotSatisfiable, err)
 		}
+		return
+	}
 
-		rsHeader = rs.Header
-		rsBody = rs.Body
+	result = newDownloadResult(r)
+	if method == http.MethodHead {
+		drainAndClose(r.Body)
+	} else {
+		result.Body = &downloadBody{ReadCloser: r.Body, expected: r.ContentLength}
 	}
 
 	return
 }
 
-func (c *httpClient) downloadByReadCloser(url string) (filename string, size int64, md map[string]string, rc io.ReadCloser, err error) {
-	r, err := c.client.Get(url)
+func (c *httpClient) download(url string, header http.Header, callback func(*DownloadResult) error) (result *DownloadResult, err error) {
+	result, err = c.fetch(http.MethodGet, url, header)
 	if err == nil {
-		if r.StatusCode != http.StatusOK {
-			drainAndClose(r.Body)
-			err = fmt.Errorf("Download %s but error. Status:%s", url, r.Status)
-
-			if r.StatusCode == http.StatusNotFound {
-				err = fmt.Errorf("%w: %v", ErrFileNotFound, err)
-			}
-			return
-		}
-
-		contentDisposition := r.Header["Content-Disposition"]
-		if len(contentDisposition) > 0 {
-			if i := strings.Index(contentDisposition[0], "filename="); i >= 0 {
-				filename = contentDisposition[0][i+len("filename="):]
-				filename = strings.Trim(filename, "\"")
-			}
-		}
-
-		contentLength := r.Header["Content-Length"]
-		if len(contentLength) > 0 {
-			size, _ = strconv.ParseInt(contentLength[0], 10, 64)
-		}
-
-		md = make(map[string]string, len(r.Header))
-		for k, v := range r.Header {
-			if len(v) == 0 {
-				continue
-			}
-			md[k] = v[0]
-		}
-
-		rc = r.Body
+		// execute callback, then drain and close body
+		err = c.finishDownload(result, callback(result))
+		result.Body = nil
 	}
-
 	return
 }
 
-func (c *httpClient) downloadByReadCloserWithHTTPRange(url string, ranges string) (filename string, size int64, md map[string]string, rc io.ReadCloser, err error) {
+// upload streams content as multipart form, retrying transient failures if enabled (see WithUploadRetry).
+// Size is the declared content length, which is verified while streaming if positive. Internal parts
+// (chunks, manifests) pass negative size to skip upload validation.
+func (c *httpClient) upload(url string, filename string, fileReader io.Reader, size int64, mtype string, header http.Header, out interface{}) (statusCode int, err error) {
+	return c.uploadContext(context.Background(), url, filename, fileReader, size, mtype, header, out)
+}
 
-	r, err := func() (*http.Response, error) {
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return nil, err
-		}
-		if len(ranges) > 0 {
-			req.Header.Set("Range", ranges)
-		}
-		return c.client.Do(req)
-	}()
+// uploadContext is like upload, aborting request and releasing streaming task once ctx is done.
+func (c *httpClient) uploadContext(ctx context.Context, url string, filename string, fileReader io.Reader, size int64, mtype string, header http.Header, out interface{}) (statusCode int, err error) {
+	if c.dryRun(http.MethodPost, url) {
+		return http.StatusOK, nil
+	}
 
-	if err == nil {
-		if r.StatusCode != http.StatusOK {
-			drainAndClose(r.Body)
-			err = fmt.Errorf("Download %s but error. Status:%s", url, r.Status)
+	body, rewind, release, err := c.rewindable(fileReader, size)
+	if err != nil {
+		return
+	}
+	if release != nil {
+		defer release()
+	}
 
-			if r.StatusCode == http.StatusNotFound {
-				err = fmt.Errorf("%w: %v", ErrFileNotFound, err)
-			}
+	for attempt := 0; ; attempt++ {
+		statusCode, err = c.uploadOnce(ctx, url, filename, body, size, mtype, header, out, rewind != nil || release != nil)
+		if err == nil || rewind == nil || attempt >= c.opts.uploadRetries || !retriable(statusCode, err) {
 			return
 		}
 
-		contentDisposition := r.Header["Content-Disposition"]
-		if len(contentDisposition) > 0 {
-			if i := strings.Index(contentDisposition[0], "filename="); i >= 0 {
-				filename = contentDisposition[0][i+len("filename="):]
-				filename = strings.Trim(filename, "\"")
-			}
-		}
-
-		contentLength := r.Header["Content-Length"]
-		if len(contentLength) > 0 {
-			size, _ = strconv.ParseInt(contentLength[0], 10, 64)
+		select {
+		case <-time.After(backoff(c.opts.retryBackoff, attempt)):
+		case <-ctx.Done():
+			return statusCode, ctx.Err()
 		}
-
-		md = make(map[string]string, len(r.Header))
-		for k, v := range r.Header {
-			if len(v) == 0 {
-				continue
-			}
-			md[k] = v[0]
+		if body, err = rewind(); err != nil {
+			return
 		}
+	}
+}
 
-		rc = r.Body
+// uploadOnce makes a single upload attempt. If wait, a failed attempt waits for content streaming to stop,
+// so content could be rewound, or its retry buffer released, safely.
+func (c *httpClient) uploadOnce(ctx context.Context, url string, filename string, fileReader io.Reader, size int64, mtype string, header http.Header, out interface{}, wait bool) (statusCode int, err error) {
+	if mtype, fileReader, err = resolveMimeType(filename, mtype, fileReader); err != nil {
+		return
 	}
 
-	return
-}
+	if size >= 0 {
+		if err = c.checkUploadSize(filename, size); err != nil {
+			return
+		}
+		if err = c.checkMimeType(filename, mtype); err != nil {
+			return
+		}
+		fileReader = &uploadGuard{r: fileReader, filename: filename, expected: size, limit: c.opts.maxUploadSize}
+	}
 
-func (c *httpClient) upload(url string, filename string, fileReader io.Reader, mtype string, extraMetadata map[string]string) (respBody []byte, statusCode int, err error) {
 	r, w := io.Pipe()
 
 	// create multipart writer
 	mw := multipart.NewWriter(w)
 
-	task := workerpool.NewTask(context.Background(), func(ctx context.Context) (interface{}, error) {
-		h := make(textproto.MIMEHeader)
-		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, normalizeName(filename)))
-		if mtype == "" {
-			mtype = mime.TypeByExtension(strings.ToLower(filepath.Ext(filename)))
+	task := workerpool.NewTask(ctx, func(ctx context.Context) (interface{}, error) {
+		if err := ctx.Err(); err != nil { // canceled while queued
+			_ = w.CloseWithError(err)
+			return nil, err
 		}
+
+		// unblock writing into pipe once canceled
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				_ = w.CloseWithError(ctx.Err())
+			case <-done:
+			}
+		}()
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", formDataDisposition("file", filename))
 		if mtype != "" {
 			h.Set("Content-Type", mtype)
 		}
 
 		part, err := mw.CreatePart(h)
 		if err == nil {
-			_, err = io.Copy(part, fileReader)
+			_, err = io.Copy(part, &contextReader{ctx: ctx, r: fileReader})
 		}
 
 		if err == nil {
 			if err = mw.Close(); err == nil {
 				err = w.Close()
 			} else {
-				_ = w.Close()
+				_ = w.CloseWithError(err)
 			}
 		} else {
-			_ = mw.Close()
-			_ = w.Close()
+			// abort request rather than terminating multipart, which would be accepted as a truncated file
+			_ = w.CloseWithError(err)
 		}
 
 		return nil, err
 	})
 	c.workers.Do(task)
 
-	req, err := http.NewRequest("POST", url, r)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, r)
 	if err != nil {
-		return nil, 0, err
+		_ = r.CloseWithError(err)
+		<-task.Result()
+		return 0, err
 	}
 
-	req.Header.Set("Content-Type", mw.FormDataContentType())
-	for k, v := range extraMetadata {
-		req.Header.Set("Seaweed-"+k, v)
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
 	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
 	var resp *http.Response
-	resp, err = c.client.Do(req)
+	resp, err = c.do(req)
 
 	// closing reader in case Posting error.
 	// This causes pipe writer fail to write and stop above task.
 	_ = r.Close()
 
 	if err == nil {
-		if respBody, statusCode, err = readAll(resp); err == nil {
+		if resp.StatusCode == http.StatusPreconditionFailed && header.Get("If-None-Match") != "" {
+			statusCode, err = resp.StatusCode, ErrAlreadyExists
+			drainAndClose(resp.Body)
+		} else if resp.StatusCode >= http.StatusBadRequest {
+			var body []byte
+			if body, statusCode, err = readAll(resp, c.opts.maxResponseSize); err == nil {
+				err = writeError(responseError("Upload", url, body, statusCode))
+			}
+		} else if out != nil {
+			if statusCode, err = decodeJSON(resp, c.opts.maxResponseSize, out); err == nil {
+				if setter, ok := out.(responseHeaderSetter); ok {
+					setter.setHeader(resp.Header)
+				}
+			}
+		} else {
+			statusCode = resp.StatusCode
+			drainAndClose(resp.Body)
+		}
+
+		if err == nil {
 			result := <-task.Result()
 			err = result.Err
+			return
 		}
 	}
 
+	if wait {
+		<-task.Result()
+	}
+
 	return
 }
@@ -0,0 +1,120 @@
+package goseaweedfs
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// localityRefreshInterval is how often topology is re-fetched to locate volume servers in racks.
+const localityRefreshInterval = time.Minute
+
+const (
+	sameRack = iota
+	sameDataCenter
+	remote
+)
+
+type placement struct {
+	dataCenter string
+	rack       string
+}
+
+// locality orders replica locations by proximity to the client.
+type locality struct {
+	placement
+	status func() (*SystemStatus, error)
+	now    func() time.Time
+
+	mu         sync.Mutex
+	nodes      map[string]placement
+	refreshed  time.Time
+	refreshing bool
+}
+
+func newLocality(dataCenter, rack string, status func() (*SystemStatus, error)) *locality {
+	return &locality{
+		placement: placement{dataCenter: dataCenter, rack: rack},
+		status:    status,
+		now:       time.Now,
+	}
+}
+
+// topology returns placement of volume servers by url and public url, refreshing it if stale.
+// Topology is only needed to know racks, data center is reported by lookup already.
+// Master is asked outside of lock by a single caller, others keep using current topology meanwhile.
+func (l *locality) topology() map[string]placement {
+	if l.rack == "" {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	refresh := !l.refreshing && now.Sub(l.refreshed) >= localityRefreshInterval
+	if refresh {
+		// keep stale topology on failure, retry on next interval
+		l.refreshed, l.refreshing = now, true
+	}
+	nodes := l.nodes
+	l.mu.Unlock()
+
+	if !refresh {
+		return nodes
+	}
+
+	status, err := l.status()
+	if err == nil {
+		nodes = make(map[string]placement)
+		for _, dc := range status.Topology.DataCenters {
+			for _, rack := range dc.Racks {
+				for _, node := range rack.DataNodes {
+					p := placement{dataCenter: dc.ID, rack: rack.ID}
+					nodes[node.URL], nodes[node.PublicURL] = p, p
+				}
+			}
+		}
+	}
+
+	l.mu.Lock()
+	if err == nil {
+		l.nodes = nodes
+	}
+	l.refreshing = false
+	l.mu.Unlock()
+	return nodes
+}
+
+func (l *locality) distance(loc *VolumeLocation, nodes map[string]placement) int {
+	p, ok := nodes[loc.URL]
+	if !ok {
+		p.dataCenter = loc.DataCenter
+	}
+
+	switch {
+	case p.dataCenter == "" || p.dataCenter != l.dataCenter:
+		return remote
+	case l.rack != "" && p.rack == l.rack:
+		return sameRack
+	default:
+		return sameDataCenter
+	}
+}
+
+// order sorts locations by proximity, keeping relative order of equally distant ones.
+func (l *locality) order(locations VolumeLocations) VolumeLocations {
+	nodes := l.topology()
+	sort.SliceStable(locations, func(i, j int) bool {
+		return l.distance(locations[i], nodes) < l.distance(locations[j], nodes)
+	})
+	return locations
+}
+
+// readOrder returns locations in the order they should be tried for reading: spread randomly over replicas,
+// nearest ones first if client locality is configured.
+func (c *Seaweed) readOrder(locations VolumeLocations) VolumeLocations {
+	locations = locations.ReadOrder()
+	if c.locality != nil {
+		locations = c.locality.order(locations)
+	}
+	return locations
+}
@@ -0,0 +1,79 @@
+package goseaweedfs
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalityOrder(t *testing.T) {
+	fetches := 0
+	status := func() (*SystemStatus, error) {
+		fetches++
+		return &SystemStatus{Topology: Topology{DataCenters: []*DataCenter{
+			{ID: "dc1", Racks: []*Rack{
+				{ID: "r1", DataNodes: []*DataNode{{URL: "a:8080", PublicURL: "a.public:8080"}}},
+				{ID: "r2", DataNodes: []*DataNode{{URL: "b:8080"}}},
+			}},
+			{ID: "dc2", Racks: []*Rack{
+				{ID: "r1", DataNodes: []*DataNode{{URL: "c:8080"}}},
+			}},
+		}}}, nil
+	}
+
+	locations := func() VolumeLocations {
+		return VolumeLocations{{URL: "c:8080"}, {URL: "x:8080"}, {URL: "b:8080"}, {URL: "a:8080"}}
+	}
+	urls := func(locs VolumeLocations) (result []string) {
+		for _, l := range locs {
+			result = append(result, l.URL)
+		}
+		return
+	}
+
+	l := newLocality("dc1", "r1", status)
+	require.Equal(t, []string{"a:8080", "b:8080", "c:8080", "x:8080"}, urls(l.order(locations())))
+	require.Equal(t, sameRack, l.distance(&VolumeLocation{URL: "a.public:8080"}, l.topology()))
+
+	// topology is cached
+	l.order(locations())
+	require.Equal(t, 1, fetches)
+
+	now := time.Now()
+	l.now = func() time.Time { return now.Add(localityRefreshInterval) }
+	l.status = func() (*SystemStatus, error) { fetches++; return nil, errors.New("unreachable") }
+	require.Equal(t, []string{"a:8080", "b:8080", "c:8080", "x:8080"}, urls(l.order(locations())))
+	require.Equal(t, 2, fetches)
+
+	// without rack, data center reported by lookup is used
+	l = newLocality("dc2", "", status)
+	locs := VolumeLocations{{URL: "a:8080", DataCenter: "dc1"}, {URL: "c:8080", DataCenter: "dc2"}}
+	require.Equal(t, []string{"c:8080", "a:8080"}, urls(l.order(locs)))
+	require.Equal(t, 2, fetches)
+}
+
+func TestLocalityRefreshOutsideLock(t *testing.T) {
+	release := make(chan struct{})
+	fetching := make(chan struct{})
+	status := func() (*SystemStatus, error) {
+		close(fetching)
+		<-release
+		return &SystemStatus{Topology: Topology{DataCenters: []*DataCenter{
+			{ID: "dc1", Racks: []*Rack{{ID: "r1", DataNodes: []*DataNode{{URL: "a:8080"}}}}},
+		}}}, nil
+	}
+
+	l := newLocality("dc1", "r1", status)
+	done := make(chan map[string]placement)
+	go func() { done <- l.topology() }()
+	<-fetching
+
+	// a slow master does not block other callers, which go on with current topology
+	require.Nil(t, l.topology())
+
+	close(release)
+	require.Contains(t, <-done, "a:8080")
+	require.Contains(t, l.topology(), "a:8080")
+}
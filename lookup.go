@@ -1,11 +1,19 @@
 package goseaweedfs
 
-import "math/rand"
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/url"
+)
 
 // VolumeLocation location of volume responsed from master API. According to https://github.com/chrislusf/seaweedfs/wiki/Master-Server-API
 type VolumeLocation struct {
 	URL       string `json:"url,omitempty"`
 	PublicURL string `json:"publicUrl,omitempty"`
+
+	// DataCenter of volume server, reported by newer servers.
+	DataCenter string `json:"dataCenter,omitempty"`
 }
 
 // VolumeLocations returned VolumeLocations (volumes)
@@ -29,8 +37,93 @@ func (c VolumeLocations) RandomPickForRead() *VolumeLocation {
 	return c[rand.Intn(len(c))]
 }
 
+// Unique returns locations without duplicated url, keeping order.
+// Locations of erasure coded volumes are reported once per shard, so one server might appear many times.
+func (c VolumeLocations) Unique() VolumeLocations {
+	seen := make(map[string]struct{}, len(c))
+	result := make(VolumeLocations, 0, len(c))
+	for _, loc := range c {
+		if _, ok := seen[loc.URL]; !ok {
+			seen[loc.URL] = struct{}{}
+			result = append(result, loc)
+		}
+	}
+	return result
+}
+
+// ReadOrder returns a copy of locations rotated from a random position, so reads are spread
+// over locations while still allowing failover to remaining ones.
+func (c VolumeLocations) ReadOrder() VolumeLocations {
+	if len(c) == 0 {
+		return nil
+	}
+
+	start := rand.Intn(len(c))
+	return append(append(make(VolumeLocations, 0, len(c)), c[start:]...), c[:start]...)
+}
+
 // LookupResult the result of looking up volume. According to https://github.com/chrislusf/seaweedfs/wiki/Master-Server-API
 type LookupResult struct {
 	VolumeLocations VolumeLocations `json:"locations,omitempty"`
 	Error           string          `json:"error,omitempty"`
 }
+
+// Lookuper resolves locations of volumes, replacing master lookups when configured with WithLookuper, e.g. by
+// a static mapping, a gRPC client or a sidecar service. It must be safe for concurrent use.
+// Empty locations are reported to caller as ErrFileNotFound.
+type Lookuper interface {
+	Lookup(ctx context.Context, volumeID string, args url.Values) (VolumeLocations, error)
+}
+
+// LookuperFunc adapts a function to Lookuper.
+type LookuperFunc func(ctx context.Context, volumeID string, args url.Values) (VolumeLocations, error)
+
+// Lookup calls f.
+func (f LookuperFunc) Lookup(ctx context.Context, volumeID string, args url.Values) (VolumeLocations, error) {
+	return f(ctx, volumeID, args)
+}
+
+// StaticLookuper looks up volumes from a fixed mapping of volume id to locations, for clusters of static layout.
+type StaticLookuper map[string]VolumeLocations
+
+// Lookup volume in mapping.
+func (m StaticLookuper) Lookup(_ context.Context, volumeID string, _ url.Values) (VolumeLocations, error) {
+	return m[volumeID], nil
+}
+
+// masterLookuper looks up volumes from master, hedged if enabled. Default Lookuper.
+type masterLookuper struct {
+	c *Seaweed
+}
+
+func (l *masterLookuper) Lookup(ctx context.Context, volumeID string, args url.Values) (locations VolumeLocations, err error) {
+	args = normalize(args, "", "")
+	args.Set(ParamLookupVolumeID, volumeID)
+	lookupURL := encodeURI(l.c.masterURL(), "/dir/lookup", args)
+
+	if l.c.hedger == nil {
+		return l.lookupOnce(ctx, lookupURL)
+	}
+
+	// hedge with a duplicated request to master
+	v, err := l.c.hedger.race(ctx, 2, func(ctx context.Context, _ int) (interface{}, error) {
+		return l.lookupOnce(ctx, lookupURL)
+	}, func(_ interface{}, cancel context.CancelFunc) {
+		cancel()
+	}, func(interface{}) {})
+	if err == nil {
+		locations = v.(VolumeLocations)
+	}
+	return
+}
+
+func (l *masterLookuper) lookupOnce(ctx context.Context, lookupURL string) (VolumeLocations, error) {
+	result := &LookupResult{}
+	if _, err := l.c.client.getNegotiated(ctx, lookupURL, result); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, errors.New(result.Error)
+	}
+	return result.VolumeLocations, nil
+}
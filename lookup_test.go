@@ -1,8 +1,16 @@
 package goseaweedfs
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestLookUp(t *testing.T) {
@@ -16,3 +24,110 @@ func TestLookUp(t *testing.T) {
 		t.Fatal(fmt.Errorf("VolumeLocation func random pick invalid"))
 	}
 }
+
+func TestLookUpUniqueAndReadOrder(t *testing.T) {
+	vols := VolumeLocations{
+		{URL: "a:8080"}, {URL: "b:8080"}, {URL: "a:8080"}, {URL: "c:8080"}, {URL: "b:8080"},
+	}
+
+	unique := vols.Unique()
+	if len(unique) != 3 || unique[0].URL != "a:8080" || unique[1].URL != "b:8080" || unique[2].URL != "c:8080" {
+		t.Fatal(fmt.Errorf("VolumeLocation func unique invalid"))
+	}
+
+	ordered := unique.ReadOrder()
+	if len(ordered) != 3 {
+		t.Fatal(fmt.Errorf("VolumeLocation func read order invalid"))
+	}
+	for i := range ordered {
+		if ordered[i] != unique[(i+indexOf(unique, ordered[0]))%3] {
+			t.Fatal(fmt.Errorf("VolumeLocation func read order must keep rotation"))
+		}
+	}
+
+	if VolumeLocations(nil).ReadOrder() != nil {
+		t.Fatal(fmt.Errorf("VolumeLocation func read order invalid"))
+	}
+}
+
+func indexOf(vols VolumeLocations, loc *VolumeLocation) int {
+	for i := range vols {
+		if vols[i] == loc {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestLookuper(t *testing.T) {
+	server := newFakeCluster(t)
+	defer server.Close()
+
+	sw, err := NewSeaweed(server.URL, nil, 1024, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	fp, err := sw.Upload(strings.NewReader("content"), "a.txt", 7, "", "")
+	require.Nil(t, err)
+
+	// master is unreachable, volume is located by static mapping
+	addr := server.Listener.Addr().String()
+	static, err := NewSeaweed("http://127.0.0.1:1", nil, 1024, server.Client(), WithLookuper(StaticLookuper{
+		"3": {{URL: addr, PublicURL: addr}, {URL: addr, PublicURL: addr}},
+	}))
+	require.Nil(t, err)
+	defer func() { _ = static.Close() }()
+
+	var buf bytes.Buffer
+	_, err = static.Download(fp.FileID, nil, func(r io.Reader) (err error) {
+		_, err = buf.ReadFrom(r)
+		return
+	})
+	require.Nil(t, err)
+	require.Equal(t, "content", buf.String())
+
+	lookup, err := static.Lookup("3", nil)
+	require.Nil(t, err)
+	require.Len(t, lookup.VolumeLocations, 1)
+
+	_, err = static.LookupServerByFileID("4,0a1653fd0f", nil, true)
+	require.True(t, errors.Is(err, ErrFileNotFound))
+
+	// errors of custom lookuper are passed through
+	failure := fmt.Errorf("sidecar unavailable")
+	var called []string
+	custom, err := NewSeaweed("http://127.0.0.1:1", nil, 1024, server.Client(), WithLookuper(LookuperFunc(
+		func(_ context.Context, volumeID string, args url.Values) (VolumeLocations, error) {
+			called = append(called, volumeID+"/"+args.Get(ParamLookupCollection))
+			return nil, failure
+		})))
+	require.Nil(t, err)
+	defer func() { _ = custom.Close() }()
+
+	_, err = custom.LookupFileID("5,0a1653fd0f", url.Values{ParamLookupCollection: []string{"col"}}, false)
+	require.Equal(t, failure, err)
+	require.Equal(t, []string{"5/col"}, called)
+}
+
+func TestLookupContext(t *testing.T) {
+	type key struct{}
+	var seen []interface{}
+	sw, err := NewSeaweed("http://127.0.0.1:1", nil, 1024, nil, WithLookuper(LookuperFunc(
+		func(ctx context.Context, volumeID string, _ url.Values) (VolumeLocations, error) {
+			seen = append(seen, ctx.Value(key{}))
+			return nil, ctx.Err()
+		})))
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	ctx := context.WithValue(context.Background(), key{}, "caller")
+	_, err = sw.LookupContext(ctx, "3", nil)
+	require.Nil(t, err)
+
+	// caller's context reaches lookuper when fetching
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = sw.FetchContext(canceled, "3,01637037d6", nil, nil)
+	require.True(t, errors.Is(err, context.Canceled))
+	require.Equal(t, []interface{}{"caller", "caller"}, seen)
+}
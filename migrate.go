@@ -0,0 +1,130 @@
+package goseaweedfs
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrNoFiler return when an operation requires a filer but none is configured.
+var ErrNoFiler = fmt.Errorf("No filer configured")
+
+// MigrateOption options for migrating files into another collection.
+type MigrateOption struct {
+	// Replication and TTL of migrated files. Default to master's/filer's configuration if empty.
+	Replication string
+	TTL         string
+
+	// Concurrency of MigrateBatch. Default to 4.
+	Concurrency int
+
+	// DeleteSource deletes source blob, along with its chunks if chunked, once migrated by file id.
+	// Filer entries are replaced in place, so their old chunks are always removed by filer.
+	DeleteSource bool
+}
+
+// MigrateResult result of migrating a file.
+type MigrateResult struct {
+	// Source file id or filer path.
+	Source string
+
+	// Target new file id, or filer path which stays the same.
+	Target string
+	Size   int64
+
+	Err error
+}
+
+// Migrate re-uploads a file into target collection with options' replication and TTL.
+// Source is either a file id, or a filer path (starting with "/") which is migrated through the first filer.
+func (c *Seaweed) Migrate(source, collection string, opt MigrateOption) (result *MigrateResult, err error) {
+	if strings.HasPrefix(source, "/") {
+		filers := c.Filers()
+		if len(filers) == 0 {
+			return nil, ErrNoFiler
+		}
+		return filers[0].Migrate(source, collection, opt)
+	}
+
+	r, err := c.Fetch(source, nil, nil)
+	if err != nil {
+		return
+	}
+	defer func() { _ = r.Close() }()
+
+	fp := NewFilePartFromReader(r.Body, r.Name, r.Size)
+	fp.MimeType, fp.Collection, fp.TTL, fp.Replication = r.MimeType, collection, opt.TTL, opt.Replication
+	if _, err = c.UploadFilePart(fp, r.Tags); err != nil {
+		return
+	}
+
+	if opt.DeleteSource {
+		if _, err = c.DeleteFileWithOptions(source, nil, WithCascadeChunks()); err != nil {
+			return
+		}
+	}
+
+	result = &MigrateResult{Source: source, Target: fp.FileID, Size: r.Size}
+	return
+}
+
+// MigrateBatch migrates files concurrently (see Migrate). Results are in order of sources,
+// a failed migration does not stop the others.
+func (c *Seaweed) MigrateBatch(sources []string, collection string, opt MigrateOption) []*MigrateResult {
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	results := make([]*MigrateResult, len(sources))
+	g := newTaskGroup(concurrency)
+	for i := range sources {
+		i := i
+		g.Go(func() error {
+			r, err := c.Migrate(sources[i], collection, opt)
+			if err != nil {
+				r = &MigrateResult{Source: sources[i], Err: err}
+			}
+			results[i] = r
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	return results
+}
+
+// Migrate re-uploads a file into target collection with options' replication and TTL. Entry is replaced
+// in place once new content is fully written, so readers see either the old or the new one.
+func (f *Filer) Migrate(filePath, collection string, opt MigrateOption) (result *MigrateResult, err error) {
+	r, err := f.Fetch(filePath, nil, nil)
+	if err != nil {
+		return
+	}
+	defer func() { _ = r.Close() }()
+
+	header := metadataHeader(r.Tags)
+
+	args := normalize(nil, collection, opt.TTL)
+	if opt.Replication != "" {
+		args.Set(ParamAssignReplication, opt.Replication)
+	}
+
+	quotaDone, err := f.client.reserveQuota(collection, r.Size)
+	if err != nil {
+		return
+	}
+
+	uploaded := &FilerUploadResult{}
+	defer func() { quotaDone(uploaded.Size, err) }()
+
+	if _, err = f.client.upload(encodeURI(*f.base, filePath, args), path.Base(filePath), r.Body, r.Size, r.MimeType, header, uploaded); err != nil {
+		return
+	}
+	if uploaded.Error != "" {
+		return nil, fmt.Errorf("Migrate %s: %s", filePath, uploaded.Error)
+	}
+
+	result = &MigrateResult{Source: filePath, Target: filePath, Size: uploaded.Size}
+	return
+}
@@ -0,0 +1,108 @@
+package goseaweedfs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilerMigrate(t *testing.T) {
+	var mu sync.Mutex
+	var query url.Values
+	var header http.Header
+	var content []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Seaweed-Owner", "alice")
+			fmt.Fprint(w, "content")
+
+		case http.MethodPost:
+			mu.Lock()
+			defer mu.Unlock()
+
+			query, header = r.URL.Query(), r.Header
+			f, _, err := r.FormFile("file")
+			require.Nil(t, err)
+			content, _ = ioutil.ReadAll(f)
+			fmt.Fprintf(w, `{"name":"a.txt","size":%d}`, len(content))
+		}
+	}))
+	defer server.Close()
+
+	sw, err := NewSeaweed(server.URL, []string{server.URL}, 1024, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	result, err := sw.Migrate("/dir/a.txt", "archive", MigrateOption{Replication: "001", TTL: "7d"})
+	require.Nil(t, err)
+	require.Equal(t, &MigrateResult{Source: "/dir/a.txt", Target: "/dir/a.txt", Size: 7}, result)
+	require.Equal(t, url.Values{"collection": {"archive"}, "ttl": {"7d"}, "replication": {"001"}}, query)
+	require.Equal(t, "alice", header.Get("Seaweed-Owner"))
+	require.Equal(t, "content", string(content))
+
+	results := sw.MigrateBatch([]string{"/a", "/b", "/c"}, "archive", MigrateOption{Concurrency: 2})
+	require.Len(t, results, 3)
+	for i, r := range results {
+		require.Nil(t, r.Err)
+		require.Equal(t, []string{"/a", "/b", "/c"}[i], r.Target)
+	}
+
+	noFiler, err := NewSeaweed(server.URL, nil, 1024, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = noFiler.Close() }()
+	_, err = noFiler.Migrate("/a", "archive", MigrateOption{})
+	require.Equal(t, ErrNoFiler, err)
+}
+
+func TestMigrateDeleteSource(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		addr := server.Listener.Addr().String()
+		switch {
+		case r.URL.Path == "/dir/lookup":
+			fmt.Fprintf(w, `{"locations":[{"url":%q,"publicUrl":%q}]}`, addr, addr)
+		case r.URL.Path == "/dir/assign":
+			fmt.Fprintf(w, `{"fid":"4,09","url":%q,"publicUrl":%q,"count":1}`, addr, addr)
+		case r.URL.Path == "/3,01" && r.URL.Query().Get("cm") == "false":
+			fmt.Fprint(w, `{"name":"a.txt","size":7,"chunks":[{"fid":"3,02","size":4},{"fid":"3,03","offset":4,"size":3}]}`)
+		case r.URL.Path == "/3,01" && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+			w.Header().Set("X-File-Store", "chunked")
+			w.Header().Set("Content-Length", "7")
+			fmt.Fprint(w, "content")
+		case r.Method == http.MethodPost:
+			fmt.Fprint(w, `{"name":"a.txt","size":7}`)
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	sw, err := NewSeaweed(server.URL, nil, 1024, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	result, err := sw.Migrate("3,01", "archive", MigrateOption{DeleteSource: true})
+	require.Nil(t, err)
+	require.Equal(t, "4,09", result.Target)
+
+	// manifest and both of its chunks are removed
+	require.Len(t, deleted, 3)
+	require.Equal(t, "/3,01", deleted[0])
+	require.ElementsMatch(t, []string{"/3,02", "/3,03"}, deleted[1:])
+}
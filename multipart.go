@@ -0,0 +1,109 @@
+package goseaweedfs
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ErrNotFilePart returned when a multipart part does not carry a file, i.e. has no filename.
+var ErrNotFilePart = fmt.Errorf("Not a file part")
+
+// multipartFileName returns base name of part's filename, so a client supplied name like `../x` or `..\x`
+// can not escape the directory it is uploaded into. Returns empty string if nothing usable is left.
+func multipartFileName(part *multipart.Part) string {
+	name := path.Base(strings.ReplaceAll(part.FileName(), `\`, "/"))
+	if name == "." || name == ".." || name == "/" {
+		return ""
+	}
+	return normalizeName(name)
+}
+
+// filePartFromMultipart streams content of part, preserving its sanitized filename and content type.
+// Size is unknown, so content is never split into chunks.
+func filePartFromMultipart(part *multipart.Part) (fp *FilePart, err error) {
+	name := multipartFileName(part)
+	if name == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNotFilePart, part.FormName())
+	}
+
+	fp = NewFilePartFromReader(nopCloser(part), name, 0)
+	fp.MimeType = part.Header.Get("Content-Type")
+	return
+}
+
+// eachFilePart calls fn for every file part of multipart request, in order, skipping other form fields.
+func eachFilePart(r *http.Request, fn func(part *multipart.Part) error) error {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return err
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if part.FileName() != "" {
+			err = fn(part)
+		}
+		_ = part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// UploadFromMultipart streams file part of an incoming multipart form (e.g. browser upload) to a volume server,
+// without buffering to disk or memory.
+func (c *Seaweed) UploadFromMultipart(part *multipart.Part, collection, ttl string, opts ...UploadOption) (fp *FilePart, err error) {
+	if fp, err = filePartFromMultipart(part); err == nil {
+		fp.Collection, fp.TTL = collection, ttl
+		_, err = c.UploadFilePart(fp, nil, opts...)
+	}
+	return
+}
+
+// UploadFromRequest streams every file of an incoming multipart request to volume servers, one by one.
+// Returns uploaded files so far on error.
+func (c *Seaweed) UploadFromRequest(r *http.Request, collection, ttl string, opts ...UploadOption) (fps []*FilePart, err error) {
+	err = eachFilePart(r, func(part *multipart.Part) error {
+		fp, err := c.UploadFromMultipart(part, collection, ttl, opts...)
+		if err == nil {
+			fps = append(fps, fp)
+		}
+		return err
+	})
+	return
+}
+
+// UploadFromMultipart streams file part of an incoming multipart form (e.g. browser upload) into dir,
+// named by its filename, without buffering to disk or memory.
+func (f *Filer) UploadFromMultipart(part *multipart.Part, dir, collection, ttl string, opts ...UploadOption) (result *FilerUploadResult, err error) {
+	fp, err := filePartFromMultipart(part)
+	if err == nil {
+		fp.Collection, fp.TTL = collection, ttl
+		result, err = f.UploadFilePart(fp, path.Join("/", dir, fp.FileName), opts...)
+	}
+	return
+}
+
+// UploadFromRequest streams every file of an incoming multipart request into dir, one by one.
+// Returns uploaded files so far on error.
+func (f *Filer) UploadFromRequest(r *http.Request, dir, collection, ttl string, opts ...UploadOption) (results []*FilerUploadResult, err error) {
+	err = eachFilePart(r, func(part *multipart.Part) error {
+		result, err := f.UploadFromMultipart(part, dir, collection, ttl, opts...)
+		if err == nil {
+			results = append(results, result)
+		}
+		return err
+	})
+	return
+}
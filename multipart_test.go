@@ -0,0 +1,114 @@
+package goseaweedfs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newMultipartRequest(t *testing.T) *http.Request {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	require.Nil(t, mw.WriteField("title", "not a file"))
+
+	for _, f := range []struct{ name, mime, content string }{
+		{"icon.png", "image/png", "\x89PNG"},
+		{"config.json", "application/json", `{"a":1}`},
+	} {
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, f.name))
+		h.Set("Content-Type", f.mime)
+		w, err := mw.CreatePart(h)
+		require.Nil(t, err)
+		_, _ = w.Write([]byte(f.content))
+	}
+	require.Nil(t, mw.Close())
+
+	r := httptest.NewRequest(http.MethodPost, "/upload", &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestFilerUploadFromRequest(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, h, err := r.FormFile("file")
+		require.Nil(t, err)
+		data, _ := ioutil.ReadAll(f)
+
+		mu.Lock()
+		received = append(received, fmt.Sprintf("%s %s %s %s", r.URL.Path, h.Filename, h.Header.Get("Content-Type"), data))
+		mu.Unlock()
+		fmt.Fprintf(w, `{"name":%q,"size":%d}`, h.Filename, len(data))
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	results, err := filer.UploadFromRequest(newMultipartRequest(t), "/uploads", "", "")
+	require.Nil(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, "icon.png", results[0].Name)
+	require.Equal(t, []string{
+		"/uploads/icon.png icon.png image/png \x89PNG",
+		`/uploads/config.json config.json application/json {"a":1}`,
+	}, received)
+
+	// form fields are not files
+	mr, err := newMultipartRequest(t).MultipartReader()
+	require.Nil(t, err)
+	part, err := mr.NextPart()
+	require.Nil(t, err)
+	_, err = filer.UploadFromMultipart(part, "/uploads", "", "")
+	require.True(t, errors.Is(err, ErrNotFilePart))
+}
+
+func TestFilerUploadFromMultipartTraversal(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.URL.Path)
+		fmt.Fprint(w, `{"name":"x","size":1}`)
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	upload := func(filename string) error {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, filename))
+		w, err := mw.CreatePart(h)
+		require.Nil(t, err)
+		_, _ = w.Write([]byte("x"))
+		require.Nil(t, mw.Close())
+
+		part, err := multipart.NewReader(&buf, mw.Boundary()).NextPart()
+		require.Nil(t, err)
+		_, err = filer.UploadFromMultipart(part, "/uploads", "", "")
+		return err
+	}
+
+	require.Nil(t, upload("../../etc/passwd"))
+	require.Nil(t, upload(`..\\..\\evil.txt`))
+	require.Nil(t, upload("/abs/name.txt"))
+	require.Equal(t, []string{"/uploads/passwd", "/uploads/evil.txt", "/uploads/name.txt"}, received)
+
+	require.True(t, errors.Is(upload(".."), ErrNotFilePart))
+	require.True(t, errors.Is(upload("a/.."), ErrNotFilePart))
+	require.Len(t, received, 3)
+}
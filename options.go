@@ -0,0 +1,378 @@
+package goseaweedfs
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// DefaultMaxResponseSize is the default upper bound of response bodies which are buffered into memory (JSON results, error bodies, etc).
+// Streamed downloads are not affected by this limit.
+const DefaultMaxResponseSize = 32 << 20
+
+type options struct {
+	maxResponseSize int64
+
+	breakerThreshold int
+	breakerCooldown  time.Duration
+	breakerHook      BreakerStateHook
+
+	hedgePercentile float64
+	hedgeMinDelay   time.Duration
+	leastLoaded     bool
+
+	masterResolver  Resolver
+	filerResolver   Resolver
+	resolveInterval time.Duration
+	lookuper        Lookuper
+	transport       Transport
+
+	dryRun     bool
+	dryRunHook DryRunHook
+
+	maxUploadSize    int64
+	allowedMimeTypes []string
+
+	dataCenter string
+	rack       string
+
+	userAgent string
+	requestID bool
+
+	uploadRetries   int
+	retryBackoff    time.Duration
+	retryBufferSize int64
+
+	codecs []Codec
+
+	chunkConcurrency int
+
+	redirectPolicy *RedirectPolicy
+
+	inlineThreshold int64
+
+	quotaChecker QuotaChecker
+
+	verifyDownloads bool
+}
+
+func defaultOptions() *options {
+	return &options{
+		maxResponseSize: DefaultMaxResponseSize,
+		resolveInterval: DefaultResolveInterval,
+		retryBufferSize: DefaultRetryBufferSize,
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := defaultOptions()
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// Option customizes Seaweed and Filer clients.
+type Option func(*options)
+
+// WithMaxResponseSize limits size of response bodies which are buffered into memory.
+// Bigger responses are rejected with ErrResponseTooLarge. Non-positive value disables the guard.
+func WithMaxResponseSize(size int64) Option {
+	return func(o *options) {
+		o.maxResponseSize = size
+	}
+}
+
+// WithCircuitBreaker enables circuit breaker per target host (master, volume servers, filers).
+// After threshold consecutive failures (transport errors or 502/503/504), requests to the host fail fast
+// with ErrCircuitOpen. Once cooldown elapsed, a single probe request is let through to check if host recovered.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(o *options) {
+		o.breakerThreshold = threshold
+		o.breakerCooldown = cooldown
+	}
+}
+
+// WithBreakerStateHook sets callback which is called whenever circuit breaker of a host changes state,
+// e.g. to export it as metrics.
+func WithBreakerStateHook(hook BreakerStateHook) Option {
+	return func(o *options) {
+		o.breakerHook = hook
+	}
+}
+
+// WithHedging enables hedged reads: if a lookup or download did not respond within given percentile
+// (e.g. 0.95) of recent read latencies, but at least minDelay, a second request is sent to another replica
+// and the first response wins.
+func WithHedging(percentile float64, minDelay time.Duration) Option {
+	return func(o *options) {
+		o.hedgePercentile = percentile
+		o.hedgeMinDelay = minDelay
+	}
+}
+
+// WithLeastLoaded makes uploads pick target among writable locations of assigned volume based on recent
+// client-side latency and errors (power of two choices), instead of always using the first location.
+func WithLeastLoaded() Option {
+	return func(o *options) {
+		o.leastLoaded = true
+	}
+}
+
+// WithLocality tells data center and rack (optional) the client runs in, so reads prefer replicas in the same
+// rack, then the same data center, and fall back to other data centers only on failure.
+func WithLocality(dataCenter, rack string) Option {
+	return func(o *options) {
+		o.dataCenter, o.rack = dataCenter, rack
+	}
+}
+
+// WithParallelChunks uploads chunks of large files concurrently, up to concurrency at a time, if file reader
+// supports io.ReaderAt (e.g. *os.File). Other readers are still uploaded chunk by chunk.
+func WithParallelChunks(concurrency int) Option {
+	return func(o *options) {
+		o.chunkConcurrency = concurrency
+	}
+}
+
+// WithInlineThreshold makes filer uploads of known size up to threshold bytes stored inline in entry metadata,
+// avoiding a volume server round trip (see WithSaveInside).
+func WithInlineThreshold(threshold int64) Option {
+	return func(o *options) {
+		o.inlineThreshold = threshold
+	}
+}
+
+// WithUserAgent sets User-Agent header of every request.
+func WithUserAgent(ua string) Option {
+	return func(o *options) {
+		o.userAgent = ua
+	}
+}
+
+// WithRequestID sends X-Request-ID header with every request, so server logs could be correlated with
+// application traces. Id is taken from request context (see ContextWithRequestID), or generated randomly.
+func WithRequestID() Option {
+	return func(o *options) {
+		o.requestID = true
+	}
+}
+
+// WithUploadRetry retries uploads failed transiently (network errors, 502/503/504) up to retries times,
+// waiting backoff before the first retry and doubling it after each one. Content is rewound for retrying
+// if it is seekable (io.Seeker), has a body factory (see WithBodyFactory), or is small enough to be
+// buffered (see WithRetryBufferSize); other uploads are not retried.
+func WithUploadRetry(retries int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.uploadRetries, o.retryBackoff = retries, backoff
+	}
+}
+
+// WithRetryBufferSize sets max size of upload content which is buffered in memory for retrying.
+// Default to DefaultRetryBufferSize.
+func WithRetryBufferSize(size int64) Option {
+	return func(o *options) {
+		o.retryBufferSize = size
+	}
+}
+
+// WithCodecs negotiates compact encodings (e.g. msgpack) of master lookup and assign responses,
+// in preference order. Responses of other content types are decoded as JSON.
+func WithCodecs(codecs ...Codec) Option {
+	return func(o *options) {
+		o.codecs = codecs
+	}
+}
+
+// WithMasterResolver discovers master endpoint with resolver, which is re-resolved periodically (see WithResolveInterval).
+// Configured master url, if any, is used as fallback when initial resolving fails.
+func WithMasterResolver(r Resolver) Option {
+	return func(o *options) {
+		o.masterResolver = r
+	}
+}
+
+// WithFilerResolver discovers filer endpoints with resolver, which is re-resolved periodically (see WithResolveInterval).
+func WithFilerResolver(r Resolver) Option {
+	return func(o *options) {
+		o.filerResolver = r
+	}
+}
+
+// WithLookuper replaces looking up volume locations from master by lookuper, see Lookuper.
+func WithLookuper(lookuper Lookuper) Option {
+	return func(o *options) {
+		o.lookuper = lookuper
+	}
+}
+
+// WithTransport carries assign, lookup, filer listing and metadata subscription over transport instead of
+// HTTP+JSON, see Transport. Lookuper configured with WithLookuper still takes precedence for lookups.
+func WithTransport(t Transport) Option {
+	return func(o *options) {
+		o.transport = t
+	}
+}
+
+// WithResolveInterval sets interval of re-resolving master/filer endpoints. Default to DefaultResolveInterval.
+func WithResolveInterval(interval time.Duration) Option {
+	return func(o *options) {
+		if interval > 0 {
+			o.resolveInterval = interval
+		}
+	}
+}
+
+// WithMaxUploadSize rejects uploads bigger than size with ErrUploadTooLarge, before they are sent if their size
+// is declared, or as soon as streamed content exceeds it otherwise. Non-positive value disables the limit.
+func WithMaxUploadSize(size int64) Option {
+	return func(o *options) {
+		o.maxUploadSize = size
+	}
+}
+
+// WithAllowedMimeTypes rejects uploads whose mime type (given, by extension, or sniffed from content) is not
+// one of types with ErrMimeTypeNotAllowed. Types could be wildcards like "image/*".
+func WithAllowedMimeTypes(types ...string) Option {
+	return func(o *options) {
+		o.allowedMimeTypes = types
+	}
+}
+
+// DryRunHook is notified of every request skipped under dry run mode.
+type DryRunHook func(method, url string)
+
+// WithDryRun enables dry run mode: requests changing data (uploads, delete, rename/move, mkdir, tagging) are
+// reported to hook instead of being sent, and treated as succeeded. Skipped uploads consume nothing of their
+// content and leave results empty. Operations built on them (replace, migrate, snapshot, sync scripts) are
+// covered as well. Reads are not affected. Hook might be nil.
+func WithDryRun(hook DryRunHook) Option {
+	return func(o *options) {
+		o.dryRun, o.dryRunHook = true, hook
+	}
+}
+
+type uploadOptions struct {
+	exclusive      bool
+	replicationAck bool
+	idempotencyKey string
+	getBody        func() (io.Reader, error)
+	saveInside     bool
+	ctx            context.Context
+}
+
+func newUploadOptions(opts []UploadOption) *uploadOptions {
+	o := &uploadOptions{ctx: context.Background()}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// UploadOption customizes a single upload.
+type UploadOption func(*uploadOptions)
+
+// WithContext sets context of upload. Once it is done, upload is aborted, releasing its streaming resources.
+func WithContext(ctx context.Context) UploadOption {
+	return func(o *uploadOptions) {
+		if ctx != nil {
+			o.ctx = ctx
+		}
+	}
+}
+
+// WithExclusiveCreate makes filer upload fail with ErrAlreadyExists instead of overwriting an existing file.
+// Upload is sent with If-None-Match: *, so the filer checks and creates atomically. Filers too old to honor it
+// are refused with ErrUnsupportedByServer without writing, see Filer.Capabilities.
+func WithExclusiveCreate() UploadOption {
+	return func(o *uploadOptions) {
+		o.exclusive = true
+	}
+}
+
+// WithIdempotencyKey tags filer upload with key (stored as Seaweed-Idempotency-Key). Before writing, existing entry
+// at target path is checked: if it carries the same key, upload is skipped and existing entry is reported,
+// so retrying an upload after an ambiguous failure does not write it twice. Applies to filer uploads only.
+func WithIdempotencyKey(key string) UploadOption {
+	return func(o *uploadOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// WithSaveInside makes filer store content inline in entry metadata, instead of on a volume server.
+// Meant for tiny files (icons, small JSON blobs), see also WithInlineThreshold. Applies to filer uploads only.
+func WithSaveInside() UploadOption {
+	return func(o *uploadOptions) {
+		o.saveInside = true
+	}
+}
+
+// WithBodyFactory sets a factory re-creating upload content from the beginning, like http.Request.GetBody,
+// which is used to retry uploads of content which could not be rewound otherwise (see WithUploadRetry).
+// Ignored by chunked uploads.
+func WithBodyFactory(getBody func() (io.Reader, error)) UploadOption {
+	return func(o *uploadOptions) {
+		o.getBody = getBody
+	}
+}
+
+// WithReplicationAck makes upload verify that written file is readable from all replicas of its volume.
+// Upload fails with *ReplicationError listing unconfirmed replicas otherwise.
+func WithReplicationAck() UploadOption {
+	return func(o *uploadOptions) {
+		o.replicationAck = true
+	}
+}
+
+type deleteOptions struct {
+	strictNotFound bool
+	cascadeChunks  bool
+	keepChunks     bool
+	recursive      bool
+}
+
+func newDeleteOptions(opts []DeleteOption) *deleteOptions {
+	o := &deleteOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// DeleteOption customizes a single delete.
+type DeleteOption func(*deleteOptions)
+
+// WithStrictNotFound makes deleting a missing file fail with ErrFileNotFound, instead of being treated as succeeded.
+func WithStrictNotFound() DeleteOption {
+	return func(o *deleteOptions) {
+		o.strictNotFound = true
+	}
+}
+
+// WithCascadeChunks makes deleting a chunk manifested file (see ChunkManifest) remove all of its chunks as well.
+// Applies to deleting by file id; filer removes chunks of its entries by itself.
+func WithCascadeChunks() DeleteOption {
+	return func(o *deleteOptions) {
+		o.cascadeChunks = true
+	}
+}
+
+// WithKeepChunks makes filer remove entry only, keeping its volume blobs (chunks) in place.
+func WithKeepChunks() DeleteOption {
+	return func(o *deleteOptions) {
+		o.keepChunks = true
+	}
+}
+
+// WithRecursive makes filer delete a non-empty directory with everything under it.
+func WithRecursive() DeleteOption {
+	return func(o *deleteOptions) {
+		o.recursive = true
+	}
+}
@@ -0,0 +1,64 @@
+package goseaweedfs
+
+import (
+	"context"
+	"io"
+	"strconv"
+)
+
+// chunkFileID returns i-th file id of an assign result reserving many ids: the first one is the assigned id,
+// followed by "<fid>_1", "<fid>_2", etc.
+func chunkFileID(fileID string, i int64) string {
+	if i == 0 {
+		return fileID
+	}
+	return fileID + "_" + strconv.FormatInt(i, 10)
+}
+
+// uploadChunksAt uploads chunks of file concurrently from ra, using file ids reserved by a single assign.
+// Chunks are read from current offset of file reader, if seekable.
+func (c *Seaweed) uploadChunksAt(ctx context.Context, f *FilePart, ra io.ReaderAt, cm *ChunkManifest) (err error) {
+	var base int64
+	if seeker, ok := f.Reader.(io.Seeker); ok {
+		if base, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return
+		}
+	}
+
+	chunks := (f.FileSize + c.chunkSize - 1) / c.chunkSize
+
+	args := assignArgs(f)
+	args.Set(ParamAssignCount, strconv.FormatInt(chunks, 10))
+	reserved, err := c.Assign(args)
+	if err != nil {
+		return
+	}
+
+	cm.Chunks = make([]*ChunkInfo, chunks)
+
+	g := newTaskGroup(c.client.opts.chunkConcurrency)
+	for i := int64(0); i < chunks; i++ {
+		i := i
+		g.Go(func() error {
+			offset := i * c.chunkSize
+			size := c.chunkSize
+			if offset+size > f.FileSize {
+				size = f.FileSize - offset
+			}
+
+			assigned := *reserved
+			assigned.FileID = chunkFileID(reserved.FileID, i)
+
+			_, id, count, e := c.uploadChunk(ctx, f, io.NewSectionReader(ra, base+offset, size), cm.Name+"_"+strconv.FormatInt(i+1, 10), &assigned)
+			if e == nil {
+				cm.Chunks[i] = &ChunkInfo{
+					Offset: offset,
+					Size:   count,
+					Fid:    id,
+				}
+			}
+			return e
+		})
+	}
+	return g.Wait()
+}
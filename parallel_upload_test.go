@@ -0,0 +1,79 @@
+package goseaweedfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkFileID(t *testing.T) {
+	require.Equal(t, "3,01637037d6", chunkFileID("3,01637037d6", 0))
+	require.Equal(t, "3,01637037d6_2", chunkFileID("3,01637037d6", 2))
+}
+
+func TestUploadChunksAt(t *testing.T) {
+	var mu sync.Mutex
+	blobs := make(map[string][]byte)
+	var counts []string
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr := server.Listener.Addr().String()
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/dir/assign":
+			counts = append(counts, r.URL.Query().Get(ParamAssignCount))
+			fmt.Fprintf(w, `{"fid":"3,%02x","url":%q,"publicUrl":%q,"count":1}`, len(counts), addr, addr)
+		case r.Method == http.MethodPost:
+			f, _, err := r.FormFile("file")
+			require.Nil(t, err)
+			data, _ := ioutil.ReadAll(f)
+			blobs[r.URL.Path] = data
+			fmt.Fprintf(w, `{"name":"f","size":%d}`, len(data))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	content := bytes.Repeat([]byte("0123456789"), 10)
+	file := filepath.Join(t.TempDir(), "large.bin")
+	require.Nil(t, ioutil.WriteFile(file, content, 0600))
+
+	sw, err := NewSeaweed(server.URL, nil, 30, server.Client(), WithParallelChunks(3))
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	fp, err := NewFilePart(file)
+	require.Nil(t, err)
+	defer func() { _ = fp.Close() }()
+	_, isFile := fp.Reader.(*os.File)
+	require.True(t, isFile)
+
+	cm, err := sw.UploadFilePart(fp, nil)
+	require.Nil(t, err)
+
+	// one assign for manifest, another one reserving ids of all chunks
+	require.Equal(t, []string{"", "4"}, counts)
+	require.Len(t, cm.Chunks, 4)
+
+	var uploaded []byte
+	for i, ci := range cm.Chunks {
+		require.Equal(t, chunkFileID("3,02", int64(i)), ci.Fid)
+		require.EqualValues(t, i*30, ci.Offset)
+		uploaded = append(uploaded, blobs["/"+ci.Fid]...)
+	}
+	require.Equal(t, content, uploaded)
+	require.EqualValues(t, 10, cm.Chunks[3].Size)
+	require.Contains(t, blobs, "/3,01")
+}
@@ -0,0 +1,19 @@
+//go:build linux
+// +build linux
+
+package goseaweedfs
+
+import (
+	"os"
+	"syscall"
+)
+
+// preallocate reserves disk blocks of file up to size, extending it. Falls back to ftruncate if file system
+// does not support fallocate.
+func preallocate(file *os.File, size int64) error {
+	err := syscall.Fallocate(int(file.Fd()), 0, 0, size)
+	if err == syscall.EOPNOTSUPP || err == syscall.ENOSYS {
+		return file.Truncate(size)
+	}
+	return err
+}
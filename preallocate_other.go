@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package goseaweedfs
+
+import "os"
+
+// preallocate extends file up to size, so file system could allocate it at once.
+func preallocate(file *os.File, size int64) error {
+	return file.Truncate(size)
+}
@@ -0,0 +1,156 @@
+package goseaweedfs
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// URLOption customizes public URLs, see Seaweed.PublicURL and Filer.PublicURL.
+type URLOption func(*urlOptions)
+
+type urlOptions struct {
+	base     *url.URL
+	fileName string
+	args     url.Values
+	sign     func(u *url.URL)
+}
+
+func newURLOptions(opts []URLOption) *urlOptions {
+	o := &urlOptions{args: make(url.Values)}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// WithPublicBase builds URLs on given base (e.g. a CDN in front of the cluster) instead of server address.
+// Invalid base is ignored.
+func WithPublicBase(base string) URLOption {
+	return func(o *urlOptions) {
+		if u, err := parseURI(base); err == nil {
+			o.base = u
+		}
+	}
+}
+
+// WithFileName appends file name to volume URL (e.g. /3/01637037d6/photo.jpg), so browsers and caches see
+// a meaningful name and extension. Ignored by filer URLs.
+func WithFileName(name string) URLOption {
+	return func(o *urlOptions) {
+		o.fileName = name
+	}
+}
+
+// WithReadDeleted makes URL read deleted but not yet vacuumed content.
+func WithReadDeleted() URLOption {
+	return func(o *urlOptions) {
+		o.args.Set("readDeleted", "true")
+	}
+}
+
+// WithResize makes server resize images to width and height (0 keeps aspect ratio).
+// Mode is either "" (keep aspect ratio), "fit" or "fill".
+func WithResize(width, height int, mode string) URLOption {
+	return func(o *urlOptions) {
+		if width > 0 {
+			o.args.Set("width", strconv.Itoa(width))
+		}
+		if height > 0 {
+			o.args.Set("height", strconv.Itoa(height))
+		}
+		if mode != "" {
+			o.args.Set("mode", mode)
+		}
+	}
+}
+
+// WithJWT adds read token, which servers secured by JWT accept as "jwt" query param.
+func WithJWT(token string) URLOption {
+	return func(o *urlOptions) {
+		o.args.Set("jwt", token)
+	}
+}
+
+// WithURLSigner signs URL once built, e.g. adding signature query params checked by a CDN or proxy.
+func WithURLSigner(sign func(u *url.URL)) URLOption {
+	return func(o *urlOptions) {
+		o.sign = sign
+	}
+}
+
+// build finalizes u: applies query params in stable (sorted) order so equal URLs are cached once, then signs it.
+func (o *urlOptions) build(u *url.URL) string {
+	query := u.Query()
+	for k, vs := range o.args {
+		query[k] = vs
+	}
+	u.RawQuery = query.Encode()
+
+	if o.sign != nil {
+		o.sign(u)
+	}
+	return u.String()
+}
+
+// PublicURL builds public URL of a file, which browsers or a CDN could read from directly. Unless a public base
+// is given, volume server is picked deterministically (nearest first if locality is configured), so the same file
+// always maps to the same URL and caches stay warm.
+func (c *Seaweed) PublicURL(fileID string, opts ...URLOption) (string, error) {
+	o := newURLOptions(opts)
+
+	volID, err := parseVolumeID(fileID)
+	if err != nil {
+		return "", err
+	}
+
+	var u url.URL
+	if o.base != nil {
+		u = *o.base
+	} else {
+		locations, err := c.lookupFileLocations(context.Background(), fileID, nil)
+		if err != nil {
+			return "", err
+		}
+
+		locations = append(VolumeLocations(nil), locations...)
+		sort.SliceStable(locations, func(i, j int) bool {
+			return locations[i].PublicURL < locations[j].PublicURL
+		})
+		if c.locality != nil {
+			locations = c.locality.order(locations)
+		}
+
+		u = c.masterURL()
+		u.Host, u.RawQuery = locations.Head().PublicURL, ""
+	}
+
+	if o.fileName != "" {
+		// "<volume id>/<key><cookie>/<file name>" form
+		key := fileID[len(volID)+1:]
+		u.Path = path.Join("/", u.Path, volID, key, o.fileName)
+	} else {
+		u.Path = path.Join("/", u.Path, fileID)
+	}
+	return o.build(&u), nil
+}
+
+// PublicURL builds public URL of a filer entry, on public base if given.
+func (f *Filer) PublicURL(filePath string, opts ...URLOption) string {
+	o := newURLOptions(opts)
+
+	u := *f.base
+	if o.base != nil {
+		u = *o.base
+	}
+	u.Path = path.Join("/", u.Path, filePath)
+	if strings.HasSuffix(filePath, "/") && !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return o.build(&u)
+}
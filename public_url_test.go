@@ -0,0 +1,41 @@
+package goseaweedfs
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublicURL(t *testing.T) {
+	sw, done := newTestMaster(t, map[string]string{
+		"/dir/lookup": `{"locations":[{"url":"10.0.0.2:8080","publicUrl":"b.example.com"},{"url":"10.0.0.1:8080","publicUrl":"a.example.com"}]}`,
+	})
+	defer done()
+
+	// deterministic replica, stable params order
+	for i := 0; i < 5; i++ {
+		u, err := sw.PublicURL("3,01637037d6", WithResize(100, 0, "fit"), WithReadDeleted())
+		require.Nil(t, err)
+		require.Equal(t, "http://a.example.com/3,01637037d6?mode=fit&readDeleted=true&width=100", u)
+	}
+
+	u, err := sw.PublicURL("3,01637037d6", WithPublicBase("https://cdn.example.com/sw"), WithFileName("photo.jpg"), WithJWT("token"))
+	require.Nil(t, err)
+	require.Equal(t, "https://cdn.example.com/sw/3/01637037d6/photo.jpg?jwt=token", u)
+
+	_, err = sw.PublicURL("invalid")
+	require.NotNil(t, err)
+
+	filer, err := NewFiler("http://filer:8888", nil)
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	sign := WithURLSigner(func(u *url.URL) {
+		q := u.Query()
+		q.Set("sig", "signed:"+u.Path)
+		u.RawQuery = q.Encode()
+	})
+	require.Equal(t, "http://filer:8888/dir/a%20b.txt?sig=signed%3A%2Fdir%2Fa+b.txt", filer.PublicURL("/dir/a b.txt", sign))
+	require.Equal(t, "https://cdn.example.com/dir/", filer.PublicURL("dir/", WithPublicBase("https://cdn.example.com")))
+}
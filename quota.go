@@ -0,0 +1,45 @@
+package goseaweedfs
+
+import "fmt"
+
+// ErrQuotaExceeded should be returned (or wrapped) by QuotaChecker rejecting an upload.
+var ErrQuotaExceeded = fmt.Errorf("Quota exceeded")
+
+// QuotaChecker accounts usage of uploads at application level, see WithQuotaChecker.
+// It must be safe for concurrent use.
+type QuotaChecker interface {
+	// BeforeUpload is called before an upload with target collection and declared size (0 if unknown).
+	// Returning an error rejects upload before anything is sent.
+	BeforeUpload(collection string, size int64) error
+
+	// AfterUpload is called once an accepted upload finished, with bytes written as reported by server.
+	// Written is 0 if upload failed.
+	AfterUpload(collection string, written int64, err error)
+}
+
+// WithQuotaChecker calls checker around every file upload of Seaweed, Filer and VolumeAdmin clients, including
+// submits, migrations, snapshot copies and needle writes (internal chunks and manifests are accounted as part
+// of their file).
+func WithQuotaChecker(checker QuotaChecker) Option {
+	return func(o *options) {
+		o.quotaChecker = checker
+	}
+}
+
+// reserveQuota checks quota before uploading, returning function to be called once upload finished.
+func (c *httpClient) reserveQuota(collection string, size int64) (done func(written int64, err error), err error) {
+	q := c.opts.quotaChecker
+	if q == nil {
+		return func(int64, error) {}, nil
+	}
+
+	if err = q.BeforeUpload(collection, size); err != nil {
+		return
+	}
+	return func(written int64, err error) {
+		if err != nil {
+			written = 0
+		}
+		q.AfterUpload(collection, written, err)
+	}, nil
+}
@@ -0,0 +1,104 @@
+package goseaweedfs
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testQuota limits bytes written per collection.
+type testQuota struct {
+	mu    sync.Mutex
+	limit int64
+	used  map[string]int64
+}
+
+func (q *testQuota) BeforeUpload(collection string, size int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.used[collection]+size > q.limit {
+		return fmt.Errorf("%w: %s", ErrQuotaExceeded, collection)
+	}
+	return nil
+}
+
+func (q *testQuota) AfterUpload(collection string, written int64, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.used[collection] += written
+}
+
+func TestQuotaChecker(t *testing.T) {
+	var mu sync.Mutex
+	var uploads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "fail.txt") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		mu.Lock()
+		uploads++
+		mu.Unlock()
+		fmt.Fprint(w, `{"name":"a","size":7}`)
+	}))
+	defer server.Close()
+
+	quota := &testQuota{limit: 15, used: make(map[string]int64)}
+	filer, err := NewFiler(server.URL, server.Client(), WithQuotaChecker(quota))
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	for i := 0; i < 2; i++ {
+		_, err = filer.Upload(strings.NewReader("content"), 7, "/a.txt", "tenant", "")
+		require.Nil(t, err)
+	}
+	_, err = filer.Upload(strings.NewReader("content"), 7, "/fail.txt", "other", "")
+	require.NotNil(t, err)
+
+	// rejected before sending
+	_, err = filer.Upload(strings.NewReader("content"), 7, "/a.txt", "tenant", "")
+	require.True(t, errors.Is(err, ErrQuotaExceeded))
+
+	require.Equal(t, map[string]int64{"tenant": 14, "other": 0}, quota.used)
+	require.Equal(t, 2, uploads)
+}
+
+func TestQuotaCheckerOtherUploads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, "content")
+			return
+		}
+		fmt.Fprint(w, `{"name":"a","size":7}`)
+	}))
+	defer server.Close()
+
+	quota := &testQuota{limit: 100, used: make(map[string]int64)}
+	sw, err := NewSeaweed(server.URL, []string{server.URL}, 1024, server.Client(), WithQuotaChecker(quota))
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+	filer := sw.Filers()[0]
+
+	_, err = sw.SubmitFilePart(NewFilePartFromReader(ioutil.NopCloser(strings.NewReader("content")), "a.txt", 7), url.Values{ParamCollection: {"submit"}})
+	require.Nil(t, err)
+	_, err = filer.Migrate("/a.txt", "migrate", MigrateOption{})
+	require.Nil(t, err)
+	_, err = filer.copyEntry("/a.txt", "/snap/a.txt", &FileInfo{Collection: "snapshot"})
+	require.Nil(t, err)
+	_, err = sw.VolumeAdmin(strings.TrimPrefix(server.URL, "http://")).WriteNeedle("3,01", "a.txt", strings.NewReader("content"), 7, 0, nil)
+	require.Nil(t, err)
+	require.Equal(t, map[string]int64{"submit": 7, "migrate": 7, "snapshot": 7, "": 7}, quota.used)
+
+	// rejected before sending
+	quota.limit = 7
+	_, err = filer.Migrate("/a.txt", "migrate", MigrateOption{})
+	require.True(t, errors.Is(err, ErrQuotaExceeded))
+}
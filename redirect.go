@@ -0,0 +1,86 @@
+package goseaweedfs
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ErrTooManyRedirects returned when a read is redirected more than RedirectPolicy.MaxHops times.
+var ErrTooManyRedirects = fmt.Errorf("Too many redirects")
+
+// RedirectPolicy controls how redirects of reads (e.g. volume server redirecting to a replica) are followed,
+// instead of relying on http.Client defaults, see WithRedirectPolicy.
+type RedirectPolicy struct {
+	// MaxHops is the max number of redirects followed by a read. Zero fails redirected reads.
+	MaxHops int
+
+	// RewriteHost maps redirect target host (e.g. a private address of NATed cluster) to a reachable one.
+	// Nil keeps host as is.
+	RewriteHost func(host string) string
+
+	// PreserveAuth keeps Authorization header (e.g. JWT) when redirected to another host,
+	// which is dropped by default.
+	PreserveAuth bool
+}
+
+// WithRedirectPolicy follows redirects of reads by given policy. Other requests are not affected.
+func WithRedirectPolicy(p RedirectPolicy) Option {
+	return func(o *options) {
+		o.redirectPolicy = &p
+	}
+}
+
+// noFollow returns copy of client which does not follow redirects by itself.
+func noFollow(client *http.Client) *http.Client {
+	var c http.Client
+	if client != nil {
+		c = *client
+	}
+	c.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &c
+}
+
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// read sends a read request (GET/HEAD), following redirects by policy if configured.
+func (c *httpClient) read(req *http.Request) (resp *http.Response, err error) {
+	p := c.opts.redirectPolicy
+	if p == nil {
+		return c.do(req)
+	}
+
+	for hops := 0; ; hops++ {
+		if resp, err = c.doWith(c.redirectClient, req); err != nil || !isRedirect(resp.StatusCode) {
+			return
+		}
+
+		location, e := resp.Location()
+		drainAndClose(resp.Body)
+		if e != nil {
+			return nil, fmt.Errorf("%s %s redirected: %w", req.Method, req.URL, e)
+		}
+		if hops >= p.MaxHops {
+			return nil, fmt.Errorf("%w: %s %s redirected to %s", ErrTooManyRedirects, req.Method, req.URL, location)
+		}
+
+		if p.RewriteHost != nil {
+			location.Host = p.RewriteHost(location.Host)
+		}
+
+		next := req.Clone(req.Context())
+		next.URL, next.Host = location, ""
+		if !p.PreserveAuth && location.Host != req.URL.Host {
+			next.Header.Del("Authorization")
+		}
+		req = next
+	}
+}
@@ -0,0 +1,66 @@
+package goseaweedfs
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectPolicy(t *testing.T) {
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "auth=%s", r.Header.Get("Authorization"))
+	}))
+	defer replica.Close()
+
+	// volume server redirects to replica by its private address
+	volume := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/loop" {
+			http.Redirect(w, r, "/loop", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, "http://10.0.0.1:8080"+r.URL.Path, http.StatusMovedPermanently)
+	}))
+	defer volume.Close()
+
+	replicaURL, _ := url.Parse(replica.URL)
+	rewrite := func(host string) string {
+		if host == "10.0.0.1:8080" {
+			return replicaURL.Host
+		}
+		return host
+	}
+	header := http.Header{"Authorization": []string{"Bearer jwt"}}
+
+	read := func(p RedirectPolicy, path string) (string, error) {
+		c := newHTTPClient(volume.Client(), newOptions([]Option{WithRedirectPolicy(p)}))
+		defer func() { _ = c.Close() }()
+
+		var body string
+		_, err := c.download(volume.URL+path, header, func(r *DownloadResult) error {
+			data, err := ioutil.ReadAll(r.Body)
+			body = string(data)
+			return err
+		})
+		return body, err
+	}
+
+	body, err := read(RedirectPolicy{MaxHops: 1, RewriteHost: rewrite, PreserveAuth: true}, "/3,01637037d6")
+	require.Nil(t, err)
+	require.Equal(t, "auth=Bearer jwt", body)
+
+	body, err = read(RedirectPolicy{MaxHops: 1, RewriteHost: rewrite}, "/3,01637037d6")
+	require.Nil(t, err)
+	require.Equal(t, "auth=", body)
+
+	_, err = read(RedirectPolicy{}, "/3,01637037d6")
+	require.True(t, errors.Is(err, ErrTooManyRedirects))
+
+	_, err = read(RedirectPolicy{MaxHops: 3}, "/loop")
+	require.True(t, errors.Is(err, ErrTooManyRedirects))
+}
@@ -0,0 +1,93 @@
+package goseaweedfs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ReplicationError return when written file could not be confirmed on all of its replicas.
+type ReplicationError struct {
+	FileID    string
+	Expected  int
+	Confirmed []string
+
+	// Failed replica locations and their errors.
+	Failed map[string]error
+}
+
+func (e *ReplicationError) Error() string {
+	failed := make([]string, 0, len(e.Failed))
+	for loc, err := range e.Failed {
+		failed = append(failed, fmt.Sprintf("%s: %v", loc, err))
+	}
+	sort.Strings(failed)
+
+	return fmt.Sprintf("File %s confirmed on %d/%d replicas. Failed: [%s]", e.FileID, len(e.Confirmed), e.Expected, strings.Join(failed, ", "))
+}
+
+// replicaCount returns number of copies of replication type "xyz", which is 1+x+y+z.
+func replicaCount(replication string) int {
+	count := 1
+	for _, c := range replication {
+		if '0' <= c && c <= '9' {
+			count += int(c - '0')
+		}
+	}
+	return count
+}
+
+func assignArgs(f *FilePart) url.Values {
+	args := normalize(nil, f.Collection, f.TTL)
+	if f.Replication != "" {
+		args.Set(ParamAssignReplication, f.Replication)
+	}
+	return args
+}
+
+func (c *Seaweed) verifyReplication(ctx context.Context, f *FilePart) (err error) {
+	volID, err := parseVolumeID(f.FileID)
+	if err != nil {
+		return
+	}
+
+	lookup, err := c.LookupContext(ctx, volID, normalize(nil, f.Collection, ""))
+	if err != nil {
+		return
+	}
+
+	e := &ReplicationError{
+		FileID:   f.FileID,
+		Expected: len(lookup.VolumeLocations),
+		Failed:   make(map[string]error),
+	}
+	if f.Replication != "" {
+		if expected := replicaCount(f.Replication); expected > e.Expected {
+			e.Expected = expected
+		}
+	}
+
+	for _, loc := range lookup.VolumeLocations {
+		base := c.masterURL()
+		base.Host = loc.URL
+
+		statusCode, _, err := c.client.probe(ctx, http.MethodHead, encodeURI(base, f.FileID, nil))
+		if err == nil && statusCode != http.StatusOK {
+			err = fmt.Errorf("Status code %d", statusCode)
+		}
+
+		if err != nil {
+			e.Failed[loc.URL] = err
+		} else {
+			e.Confirmed = append(e.Confirmed, loc.URL)
+		}
+	}
+
+	if len(e.Confirmed) < e.Expected {
+		err = e
+	}
+	return
+}
@@ -0,0 +1,83 @@
+package goseaweedfs
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaCount(t *testing.T) {
+	require.Equal(t, 1, replicaCount(""))
+	require.Equal(t, 1, replicaCount("000"))
+	require.Equal(t, 2, replicaCount("001"))
+	require.Equal(t, 4, replicaCount("210"))
+}
+
+func TestReplicationError(t *testing.T) {
+	e := &ReplicationError{
+		FileID:    "3,01637037d6",
+		Expected:  2,
+		Confirmed: []string{"localhost:8080"},
+		Failed:    map[string]error{"localhost:8081": fmt.Errorf("Status code 404")},
+	}
+	require.Equal(t, "File 3,01637037d6 confirmed on 1/2 replicas. Failed: [localhost:8081: Status code 404]", e.Error())
+}
+
+func TestReplicationAck(t *testing.T) {
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			fmt.Fprint(w, `{"name":"a.txt","size":7}`)
+		}
+	}))
+	defer good.Close()
+	lagging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer lagging.Close()
+
+	goodAddr, laggingAddr := good.Listener.Addr().String(), lagging.Listener.Addr().String()
+	master := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dir/assign":
+			fmt.Fprintf(w, `{"fid":"3,01","url":%q,"publicUrl":%q,"count":1}`, goodAddr, goodAddr)
+		case "/dir/lookup":
+			if r.URL.Query().Get("collection") == "replicated" {
+				fmt.Fprintf(w, `{"locations":[{"url":%q},{"url":%q}]}`, goodAddr, laggingAddr)
+			} else {
+				fmt.Fprintf(w, `{"locations":[{"url":%q}]}`, goodAddr)
+			}
+		}
+	}))
+	defer master.Close()
+
+	upload := func(collection, replication string) error {
+		sw, err := NewSeaweed(master.URL, nil, 1024, master.Client())
+		require.Nil(t, err)
+		defer func() { _ = sw.Close() }()
+
+		fp := NewFilePartFromReader(ioutil.NopCloser(strings.NewReader("content")), "a.txt", 7)
+		fp.Collection, fp.Replication = collection, replication
+		_, err = sw.UploadFilePart(fp, nil, WithReplicationAck())
+		return err
+	}
+
+	require.Nil(t, upload("", ""))
+
+	// volume has one copy only, while replication asks for two
+	err := upload("", "001")
+	var re *ReplicationError
+	require.True(t, errors.As(err, &re))
+	require.Equal(t, 2, re.Expected)
+	require.Equal(t, []string{goodAddr}, re.Confirmed)
+
+	err = upload("replicated", "")
+	require.True(t, errors.As(err, &re))
+	require.Equal(t, []string{goodAddr}, re.Confirmed)
+	require.Contains(t, re.Failed, laggingAddr)
+}
@@ -0,0 +1,46 @@
+package goseaweedfs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is the header carrying request id, see WithRequestID.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a context carrying request id, which is sent along requests made with it
+// when WithRequestID is enabled.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns request id carried by ctx, empty if none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// setIdentity sets User-Agent and request id of req, according to options.
+func (c *httpClient) setIdentity(req *http.Request) {
+	if c.opts.userAgent != "" {
+		req.Header.Set("User-Agent", c.opts.userAgent)
+	}
+
+	if c.opts.requestID && req.Header.Get(RequestIDHeader) == "" {
+		id := RequestIDFromContext(req.Context())
+		if id == "" {
+			id = newRequestID()
+		}
+		req.Header.Set(RequestIDHeader, id)
+	}
+}
@@ -0,0 +1,45 @@
+package goseaweedfs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIdentity(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client(), WithUserAgent("my-app/1.0"), WithRequestID())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	_, err = filer.Ping(ContextWithRequestID(context.Background(), "trace-1"))
+	require.Nil(t, err)
+	require.Equal(t, "my-app/1.0", header.Get("User-Agent"))
+	require.Equal(t, "trace-1", header.Get(RequestIDHeader))
+
+	_, err = filer.Ping(context.Background())
+	require.Nil(t, err)
+	generated := header.Get(RequestIDHeader)
+	require.Len(t, generated, 32)
+
+	_, err = filer.Ping(context.Background())
+	require.Nil(t, err)
+	require.NotEqual(t, generated, header.Get(RequestIDHeader))
+
+	plain, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = plain.Close() }()
+
+	_, err = plain.Ping(ContextWithRequestID(context.Background(), "trace-1"))
+	require.Nil(t, err)
+	require.Empty(t, header.Get(RequestIDHeader))
+	require.NotEqual(t, "my-app/1.0", header.Get("User-Agent"))
+}
@@ -1,11 +1,37 @@
 package goseaweedfs
 
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // UploadResult contains upload result after put file to SeaweedFS
-// Raw response: {"name":"go1.8.3.linux-amd64.tar.gz","size":82565628,"error":""}
+// Raw response: {"name":"go1.8.3.linux-amd64.tar.gz","size":82565628,"eTag":"4e7fb8a1","error":""}
 type UploadResult struct {
-	Name  string `json:"name,omitempty"`
-	Size  int64  `json:"size,omitempty"`
-	Error string `json:"error,omitempty"`
+	FileID string `json:"fid,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Size   int64  `json:"size,omitempty"`
+	ETag   string `json:"eTag,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	// Header raw response header.
+	Header http.Header `json:"-"`
+}
+
+func (r *UploadResult) setHeader(header http.Header) {
+	r.Header = header
+	if r.ETag == "" {
+		r.ETag = strings.Trim(header.Get("ETag"), "\"")
+	}
+}
+
+// responseHeaderSetter is implemented by upload results, which keep response header.
+type responseHeaderSetter interface {
+	setHeader(http.Header)
 }
 
 // AssignResult contains assign result.
@@ -16,6 +42,9 @@ type AssignResult struct {
 	PublicURL string `json:"publicUrl,omitempty"`
 	Count     uint64 `json:"count,omitempty"`
 	Error     string `json:"error,omitempty"`
+
+	// Replicas other writable locations of assigned volume, reported by newer servers.
+	Replicas []*VolumeLocation `json:"replicas,omitempty"`
 }
 
 // SubmitResult result of submit operation.
@@ -51,6 +80,7 @@ type Topology struct {
 
 // DataCenter stats of a datacenter
 type DataCenter struct {
+	ID    string `json:"Id"`
 	Free  int
 	Max   int
 	Racks []*Rack
@@ -58,6 +88,7 @@ type DataCenter struct {
 
 // Rack stats of racks
 type Rack struct {
+	ID        string `json:"Id"`
 	DataNodes []*DataNode
 	Free      int
 	Max       int
@@ -75,5 +106,78 @@ type DataNode struct {
 // Layout of replication/collection stats. According to https://github.com/chrislusf/seaweedfs/wiki/Master-Server-API
 type Layout struct {
 	Replication string
+	Collection  string
+	TTL         string
 	Writables   []uint64
 }
+
+// DownloadResult contains metadata of downloaded file and its body.
+// Body is nil for preview (HEAD) requests and after callback based download finished.
+type DownloadResult struct {
+	Name         string
+	Size         int64
+	MimeType     string
+	LastModified time.Time
+	ETag         string
+
+	// Tags are custom metadata which were uploaded as Seaweed- prefixed headers, keyed without prefix.
+	Tags map[string]string
+
+	// Header raw response header.
+	Header http.Header
+
+	Body io.ReadCloser
+}
+
+// Close underlying body.
+func (r *DownloadResult) Close() (err error) {
+	if r.Body != nil {
+		err = r.Body.Close()
+	}
+	return
+}
+
+// Metadata returns first value of each response header.
+func (r *DownloadResult) Metadata() map[string]string {
+	md := make(map[string]string, len(r.Header))
+	for k, v := range r.Header {
+		if len(v) == 0 {
+			continue
+		}
+		md[k] = v[0]
+	}
+	return md
+}
+
+func newDownloadResult(r *http.Response) *DownloadResult {
+	result := &DownloadResult{
+		MimeType: r.Header.Get("Content-Type"),
+		ETag:     strings.Trim(r.Header.Get("ETag"), "\""),
+		Header:   r.Header,
+		Tags:     make(map[string]string),
+	}
+
+	if contentDisposition := r.Header.Get("Content-Disposition"); contentDisposition != "" {
+		if _, params, err := mime.ParseMediaType(contentDisposition); err == nil {
+			result.Name = params["filename"]
+		} else if i := strings.Index(contentDisposition, "filename="); i >= 0 {
+			result.Name = strings.Trim(contentDisposition[i+len("filename="):], "\"")
+		}
+	}
+
+	if contentLength := r.Header.Get("Content-Length"); contentLength != "" {
+		result.Size, _ = strconv.ParseInt(contentLength, 10, 64)
+	}
+
+	if lastModified := r.Header.Get("Last-Modified"); lastModified != "" {
+		result.LastModified, _ = http.ParseTime(lastModified)
+	}
+
+	for k, v := range r.Header {
+		if len(v) > 0 && strings.HasPrefix(k, "Seaweed-") {
+			result.Tags[k[len("Seaweed-"):]] = v[0]
+		}
+	}
+
+	return result
+}
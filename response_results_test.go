@@ -0,0 +1,56 @@
+package goseaweedfs
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDownloadResult(t *testing.T) {
+	r := newTestResponse("content")
+	r.Header = http.Header{
+		"Content-Disposition": []string{`inline; filename="test.txt"`},
+		"Content-Length":      []string{"7"},
+		"Content-Type":        []string{"text/plain"},
+		"Etag":                []string{`"3b5d3c7d"`},
+		"Last-Modified":       []string{"Mon, 02 Jan 2006 15:04:05 GMT"},
+		"Seaweed-Owner":       []string{"ocean"},
+	}
+
+	result := newDownloadResult(r)
+	require.Equal(t, "test.txt", result.Name)
+	require.EqualValues(t, 7, result.Size)
+	require.Equal(t, "text/plain", result.MimeType)
+	require.Equal(t, "3b5d3c7d", result.ETag)
+	require.Equal(t, 2006, result.LastModified.Year())
+	require.Equal(t, map[string]string{"Owner": "ocean"}, result.Tags)
+	require.Equal(t, "text/plain", result.Metadata()["Content-Type"])
+
+	r.Header.Set("Content-Disposition", `inline; filename="say \"hi\".txt"`)
+	require.Equal(t, `say "hi".txt`, newDownloadResult(r).Name)
+
+	r.Header.Set("Content-Disposition", `inline; filename*=UTF-8''r%C3%A9sum%C3%A9.pdf`)
+	require.Equal(t, "résumé.pdf", newDownloadResult(r).Name)
+}
+
+func TestUploadResultHeader(t *testing.T) {
+	server := newFakeCluster(t)
+	defer server.Close()
+
+	sw, err := NewSeaweed(server.URL, []string{server.URL}, 1024, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	fp, err := sw.Upload(strings.NewReader("content"), "a.txt", 7, "", "")
+	require.Nil(t, err)
+	require.Equal(t, fp.FileID, fp.Result.FileID)
+	require.EqualValues(t, 7, fp.Result.Size)
+	require.Equal(t, "7", fp.Result.ETag)
+	require.NotEmpty(t, fp.Result.Header.Get("Date"))
+
+	result, err := sw.Filers()[0].Upload(strings.NewReader("content"), 7, "/a.txt", "", "")
+	require.Nil(t, err)
+	require.Equal(t, "7", result.ETag)
+}
@@ -0,0 +1,119 @@
+package goseaweedfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultRetryBufferSize is the default max size of upload content which is buffered in memory
+// to be retried, if it could not be rewound otherwise.
+const DefaultRetryBufferSize = 1 << 20
+
+// factoryReader carries a factory which re-creates content from the beginning, see WithBodyFactory.
+type factoryReader struct {
+	io.Reader
+	factory func() (io.Reader, error)
+}
+
+// withBodyFactory attaches body factory to r, if any.
+func withBodyFactory(r io.Reader, factory func() (io.Reader, error)) io.Reader {
+	if factory == nil {
+		return r
+	}
+	return &factoryReader{Reader: r, factory: factory}
+}
+
+// nopCloser is like ioutil.NopCloser, but keeps r seekable so upload could be rewound.
+func nopCloser(r io.Reader) io.ReadCloser {
+	if rs, ok := r.(io.ReadSeeker); ok {
+		return &nopSeekCloser{ReadSeeker: rs}
+	}
+	return nopReadCloser{Reader: r}
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+type nopReadCloser struct {
+	io.Reader
+}
+
+func (nopReadCloser) Close() error { return nil }
+
+// rewindable prepares upload content of size (negative if unknown) for retrying. Returned rewind restarts content
+// from the beginning, it is nil if content could not be rewound: not seekable, without factory, and bigger than
+// retry buffer. Content known to exceed retry buffer, by size or by limit of an internal chunk reader, is not
+// buffered at all. Returned release gives retry buffer back once upload is done, it is nil if none was taken.
+func (c *httpClient) rewindable(r io.Reader, size int64) (body io.Reader, rewind func() (io.Reader, error), release func(), err error) {
+	if c.opts.uploadRetries <= 0 {
+		return r, nil, nil, nil
+	}
+
+	if fr, ok := r.(*factoryReader); ok {
+		return fr.Reader, fr.factory, nil, nil
+	}
+
+	if seeker, ok := r.(io.Seeker); ok {
+		var offset int64
+		if offset, err = seeker.Seek(0, io.SeekCurrent); err == nil {
+			return r, func() (io.Reader, error) {
+				_, err := seeker.Seek(offset, io.SeekStart)
+				return r, err
+			}, nil, nil
+		}
+	}
+
+	if lr, ok := r.(*io.LimitedReader); ok && (size < 0 || lr.N < size) {
+		size = lr.N
+	}
+	if size > c.opts.retryBufferSize {
+		return r, nil, nil, nil
+	}
+
+	// buffer small content
+	pooled := c.retryBuffers.Get().(*[]byte)
+	release = func() { c.retryBuffers.Put(pooled) }
+
+	buf := *pooled
+	n, err := io.ReadFull(r, buf)
+	switch err {
+	case nil:
+		return io.MultiReader(bytes.NewReader(buf), r), nil, release, nil
+	case io.EOF, io.ErrUnexpectedEOF:
+		buf = buf[:n]
+		return bytes.NewReader(buf), func() (io.Reader, error) { return bytes.NewReader(buf), nil }, release, nil
+	default:
+		release()
+		return nil, nil, nil, err
+	}
+}
+
+// retriable reports whether upload failed transiently, so it could be retried.
+func retriable(statusCode int, err error) bool {
+	switch {
+	case errors.Is(err, ErrUploadTooLarge), errors.Is(err, ErrMimeTypeNotAllowed), errors.Is(err, ErrSizeMismatch),
+		errors.Is(err, ErrCircuitOpen), errors.Is(err, ErrAlreadyExists),
+		errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case statusCode == 0:
+		return err != nil
+	}
+
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns delay before the attempt-th retry, doubling from base.
+func backoff(base time.Duration, attempt int) time.Duration {
+	return base << uint(attempt)
+}
@@ -0,0 +1,166 @@
+package goseaweedfs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer fails first `failures` uploads with 503, recording content of every upload.
+func flakyServer(t *testing.T, failures int) (server *httptest.Server, received func() []string) {
+	var mu sync.Mutex
+	var contents []string
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := ioutil.ReadAll(file)
+
+		mu.Lock()
+		contents = append(contents, string(data))
+		n := len(contents)
+		mu.Unlock()
+
+		if n <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"name":"a","size":%d}`, len(data))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), contents...)
+	}
+}
+
+func TestUploadRetry(t *testing.T) {
+	t.Run("Seeker", func(t *testing.T) {
+		server, received := flakyServer(t, 2)
+		filer, err := NewFiler(server.URL, server.Client(), WithUploadRetry(2, 0))
+		require.Nil(t, err)
+		defer func() { _ = filer.Close() }()
+
+		r := strings.NewReader("xxcontent")
+		_, _ = r.Seek(2, io.SeekStart)
+		_, err = filer.Upload(r, 7, "/a.txt", "", "")
+		require.Nil(t, err)
+		require.Equal(t, []string{"content", "content", "content"}, received())
+	})
+
+	t.Run("Buffered", func(t *testing.T) {
+		server, received := flakyServer(t, 1)
+		filer, err := NewFiler(server.URL, server.Client(), WithUploadRetry(1, 0))
+		require.Nil(t, err)
+		defer func() { _ = filer.Close() }()
+
+		_, err = filer.Upload(struct{ io.Reader }{strings.NewReader("content")}, 7, "/a.txt", "", "")
+		require.Nil(t, err)
+		require.Equal(t, []string{"content", "content"}, received())
+	})
+
+	t.Run("TooLargeToBuffer", func(t *testing.T) {
+		server, received := flakyServer(t, 1)
+		filer, err := NewFiler(server.URL, server.Client(), WithUploadRetry(1, 0), WithRetryBufferSize(4))
+		require.Nil(t, err)
+		defer func() { _ = filer.Close() }()
+
+		_, err = filer.Upload(struct{ io.Reader }{strings.NewReader("content")}, 7, "/a.txt", "", "")
+		require.NotNil(t, err)
+		require.Equal(t, []string{"content"}, received())
+	})
+
+	t.Run("BodyFactory", func(t *testing.T) {
+		server, received := flakyServer(t, 1)
+		filer, err := NewFiler(server.URL, server.Client(), WithUploadRetry(1, 0), WithRetryBufferSize(4))
+		require.Nil(t, err)
+		defer func() { _ = filer.Close() }()
+
+		content := []byte("content")
+		factory := func() (io.Reader, error) { return struct{ io.Reader }{bytes.NewReader(content)}, nil }
+		r, _ := factory()
+		_, err = filer.Upload(r, 7, "/a.txt", "", "", WithBodyFactory(factory))
+		require.Nil(t, err)
+		require.Equal(t, []string{"content", "content"}, received())
+	})
+
+	t.Run("Exhausted", func(t *testing.T) {
+		server, received := flakyServer(t, 3)
+		filer, err := NewFiler(server.URL, server.Client(), WithUploadRetry(2, 0))
+		require.Nil(t, err)
+		defer func() { _ = filer.Close() }()
+
+		_, err = filer.Upload(strings.NewReader("content"), 7, "/a.txt", "", "")
+		require.NotNil(t, err)
+		require.Len(t, received(), 3)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		server, received := flakyServer(t, 1)
+		filer, err := NewFiler(server.URL, server.Client())
+		require.Nil(t, err)
+		defer func() { _ = filer.Close() }()
+
+		_, err = filer.Upload(strings.NewReader("content"), 7, "/a.txt", "", "")
+		require.NotNil(t, err)
+		require.Len(t, received(), 1)
+	})
+}
+
+func TestRewindable(t *testing.T) {
+	filer, err := NewFiler("http://127.0.0.1:1", nil, WithUploadRetry(1, 0), WithRetryBufferSize(4))
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+	c := filer.client
+
+	// content known to exceed retry buffer is passed through untouched
+	src := struct{ io.Reader }{strings.NewReader("content")}
+	body, rewind, release, err := c.rewindable(src, 7)
+	require.Nil(t, err)
+	require.Nil(t, rewind)
+	require.Nil(t, release)
+	require.Equal(t, src, body)
+
+	// so is an internal chunk bigger than retry buffer
+	chunk := io.LimitReader(src, 5)
+	body, rewind, release, err = c.rewindable(chunk, -1)
+	require.Nil(t, err)
+	require.Nil(t, rewind)
+	require.Nil(t, release)
+	require.Equal(t, chunk, body)
+
+	// small chunk is buffered into a pooled buffer
+	body, rewind, release, err = c.rewindable(io.LimitReader(strings.NewReader("content"), 3), -1)
+	require.Nil(t, err)
+	require.NotNil(t, rewind)
+	require.NotNil(t, release)
+	data, _ := ioutil.ReadAll(body)
+	require.Equal(t, "con", string(data))
+	body, _ = rewind()
+	data, _ = ioutil.ReadAll(body)
+	require.Equal(t, "con", string(data))
+	release()
+}
+
+func TestRetriable(t *testing.T) {
+	require.True(t, retriable(0, io.ErrUnexpectedEOF))
+	require.True(t, retriable(http.StatusServiceUnavailable, fmt.Errorf("unavailable")))
+	require.False(t, retriable(http.StatusBadRequest, fmt.Errorf("bad request")))
+	require.False(t, retriable(0, ErrSizeMismatch))
+	require.False(t, retriable(0, context.Canceled))
+	require.False(t, retriable(http.StatusPreconditionFailed, ErrAlreadyExists))
+}
@@ -0,0 +1,183 @@
+package goseaweedfs
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrOutsideScope returned when a path escapes path prefix of a scoped client.
+var ErrOutsideScope = fmt.Errorf("Path outside of scope")
+
+// Scoped is a lightweight view of Seaweed client with default collection, replication, TTL and filer path prefix,
+// e.g. per tenant. It shares connections, caches and daemons with its parent, so it is cheap to create
+// and needs no closing. Scoped is safe for concurrent use by multiple goroutines.
+type Scoped struct {
+	c *Seaweed
+
+	collection  string
+	replication string
+	ttl         string
+	pathPrefix  string
+}
+
+// WithDefaults returns a scoped client applying given defaults. Empty ones fall back to server defaults.
+// PathPrefix is prepended to filer paths.
+func (c *Seaweed) WithDefaults(collection, replication, ttl, pathPrefix string) *Scoped {
+	if pathPrefix != "" {
+		pathPrefix = path.Clean("/" + pathPrefix)
+	}
+	return &Scoped{
+		c:           c,
+		collection:  collection,
+		replication: replication,
+		ttl:         ttl,
+		pathPrefix:  strings.TrimSuffix(pathPrefix, "/"),
+	}
+}
+
+// Seaweed returns the parent client.
+func (s *Scoped) Seaweed() *Seaweed {
+	return s.c
+}
+
+// Collection default collection.
+func (s *Scoped) Collection() string {
+	return s.collection
+}
+
+// Path returns filer path of p under path prefix. Path is cleaned, and rejected with ErrOutsideScope if it
+// escapes prefix, e.g. by "..".
+func (s *Scoped) Path(p string) (string, error) {
+	full := path.Clean(s.pathPrefix + "/" + p)
+	if full != s.pathPrefix && !strings.HasPrefix(full, s.pathPrefix+"/") {
+		return "", fmt.Errorf("%w: %s", ErrOutsideScope, p)
+	}
+	if strings.HasSuffix(p, "/") && !strings.HasSuffix(full, "/") {
+		full += "/"
+	}
+	return full, nil
+}
+
+// args applies defaults to args, keeping values set by caller.
+func (s *Scoped) args(args url.Values) url.Values {
+	if args == nil {
+		args = make(url.Values)
+	}
+	if s.collection != "" && args.Get(ParamCollection) == "" {
+		args.Set(ParamCollection, s.collection)
+	}
+	if s.ttl != "" && args.Get(ParamTTL) == "" {
+		args.Set(ParamTTL, s.ttl)
+	}
+	if s.replication != "" && args.Get(ParamAssignReplication) == "" {
+		args.Set(ParamAssignReplication, s.replication)
+	}
+	return args
+}
+
+// filePart applies defaults to file part, keeping values set by caller.
+func (s *Scoped) filePart(fp *FilePart) *FilePart {
+	if fp.Collection == "" {
+		fp.Collection = s.collection
+	}
+	if fp.TTL == "" {
+		fp.TTL = s.ttl
+	}
+	if fp.Replication == "" {
+		fp.Replication = s.replication
+	}
+	return fp
+}
+
+// Assign do assign api with defaults.
+func (s *Scoped) Assign(args url.Values) (*AssignResult, error) {
+	return s.c.Assign(s.args(args))
+}
+
+// Upload file by reader.
+func (s *Scoped) Upload(fileReader io.Reader, fileName string, size int64, opts ...UploadOption) (fp *FilePart, err error) {
+	fp = s.filePart(NewFilePartFromReader(nopCloser(fileReader), fileName, size))
+	_, err = s.c.UploadFilePart(fp, nil, opts...)
+	return
+}
+
+// UploadFile with full file dir/path.
+func (s *Scoped) UploadFile(filePath string, opts ...UploadOption) (cm *ChunkManifest, fp *FilePart, err error) {
+	fp, err = NewFilePart(filePath)
+	if err == nil {
+		cm, err = s.c.UploadFilePart(s.filePart(fp), nil, opts...)
+		_ = fp.Close()
+	}
+	return
+}
+
+// UploadFilePart uploads a file part, applying defaults to fields which are not set.
+func (s *Scoped) UploadFilePart(fp *FilePart, extraMetadata map[string]string, opts ...UploadOption) (*ChunkManifest, error) {
+	return s.c.UploadFilePart(s.filePart(fp), extraMetadata, opts...)
+}
+
+// filer returns the first filer.
+func (s *Scoped) filer() (*Filer, error) {
+	filers := s.c.Filers()
+	if len(filers) == 0 {
+		return nil, ErrNoFiler
+	}
+	return filers[0], nil
+}
+
+// resolve returns the first filer and path of p under path prefix.
+func (s *Scoped) resolve(p string) (f *Filer, full string, err error) {
+	if full, err = s.Path(p); err == nil {
+		f, err = s.filer()
+	}
+	return
+}
+
+// FilerUpload uploads content to filer path under path prefix, through the first filer.
+func (s *Scoped) FilerUpload(content io.Reader, fileSize int64, newPath string, opts ...UploadOption) (result *FilerUploadResult, err error) {
+	f, full, err := s.resolve(newPath)
+	if err == nil {
+		fp := s.filePart(NewFilePartFromReader(nopCloser(content), newPath, fileSize))
+		result, err = f.UploadFilePart(fp, full, opts...)
+	}
+	return
+}
+
+// FilerFetch fetches a file under path prefix, through the first filer. Returned result must be closed by caller.
+func (s *Scoped) FilerFetch(filePath string, args url.Values) (result *DownloadResult, err error) {
+	f, full, err := s.resolve(filePath)
+	if err == nil {
+		result, err = f.Fetch(full, args, nil)
+	}
+	return
+}
+
+// FilerStat returns entry info under path prefix, through the first filer.
+func (s *Scoped) FilerStat(filePath string) (fi *FileInfo, err error) {
+	f, full, err := s.resolve(filePath)
+	if err == nil {
+		fi, err = f.Stat(full)
+	}
+	return
+}
+
+// FilerListDir lists all entries of a directory under path prefix, through the first filer.
+func (s *Scoped) FilerListDir(dir string) (entries []*FileInfo, err error) {
+	f, full, err := s.resolve(dir)
+	if err == nil {
+		entries, err = f.ListDir(full)
+	}
+	return
+}
+
+// FilerDelete deletes a file/dir under path prefix, through the first filer.
+func (s *Scoped) FilerDelete(filePath string, args url.Values) (err error) {
+	f, full, err := s.resolve(filePath)
+	if err == nil {
+		err = f.Delete(full, args)
+	}
+	return
+}
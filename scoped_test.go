@@ -0,0 +1,89 @@
+package goseaweedfs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopedPath(t *testing.T) {
+	s := (&Seaweed{}).WithDefaults("", "", "", "tenant/")
+	scoped := func(p string) string {
+		full, err := s.Path(p)
+		require.Nil(t, err)
+		return full
+	}
+	require.Equal(t, "/tenant/a/b", scoped("a/b"))
+	require.Equal(t, "/tenant/a/", scoped("/a/"))
+	require.Equal(t, "/tenant/", scoped("/"))
+	require.Equal(t, "/tenant/b/x", scoped("a/../b/./x"))
+	require.Equal(t, "/tenant", scoped("a/.."))
+
+	// escaping prefix
+	for _, p := range []string{"../b/x", "/../tenant2/x", "a/../../tenant2", ".."} {
+		_, err := s.Path(p)
+		require.True(t, errors.Is(err, ErrOutsideScope), p)
+	}
+
+	s = (&Seaweed{}).WithDefaults("", "", "", "")
+	require.Equal(t, "/a", scoped("a"))
+	require.Equal(t, "/b", scoped("../b"))
+}
+
+func TestScoped(t *testing.T) {
+	var mu sync.Mutex
+	var requests []*url.URL
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL)
+		mu.Unlock()
+
+		addr := server.Listener.Addr().String()
+		if r.URL.Path == "/dir/assign" {
+			fmt.Fprintf(w, `{"fid":"3,01637037d6","url":%q,"publicUrl":%q,"count":1}`, addr, addr)
+			return
+		}
+		fmt.Fprint(w, `{"name":"a","size":7}`)
+	}))
+	defer server.Close()
+
+	sw, err := NewSeaweed(server.URL, []string{server.URL}, 1024, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	s := sw.WithDefaults("tenant", "001", "1d", "/tenants/a")
+	require.Same(t, sw, s.Seaweed())
+
+	_, err = s.Upload(strings.NewReader("content"), "a.txt", 7)
+	require.Nil(t, err)
+	_, err = s.FilerUpload(strings.NewReader("content"), 7, "/dir/a.txt")
+	require.Nil(t, err)
+
+	// other tenants are out of reach, nothing is sent
+	_, err = s.FilerFetch("../b/x", nil)
+	require.True(t, errors.Is(err, ErrOutsideScope))
+	require.True(t, errors.Is(s.FilerDelete("/dir/../../b", nil), ErrOutsideScope))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, requests, 3)
+
+	assign := requests[0].Query()
+	require.Equal(t, "tenant", assign.Get(ParamCollection))
+	require.Equal(t, "001", assign.Get(ParamAssignReplication))
+	require.Equal(t, "1d", assign.Get(ParamTTL))
+	require.Equal(t, "tenant", requests[1].Query().Get(ParamCollection))
+
+	require.Equal(t, "/tenants/a/dir/a.txt", requests[2].Path)
+	require.Equal(t, "tenant", requests[2].Query().Get(ParamCollection))
+	require.Equal(t, "001", requests[2].Query().Get(ParamAssignReplication))
+}
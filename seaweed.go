@@ -3,16 +3,15 @@ package goseaweedfs
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
 	"strconv"
-	"strings"
+	"sync"
+	"sync/atomic"
 
 	workerpool "github.com/linxGnu/gumble/worker-pool"
 )
@@ -20,8 +19,29 @@ import (
 var (
 	// ErrFileNotFound return file not found error
 	ErrFileNotFound = fmt.Errorf("File not found")
+
+	// ErrAlreadyExists return when creating a file which already exists
+	ErrAlreadyExists = fmt.Errorf("File already exists")
+
+	// ErrWriterClosed return when writing to closed writer
+	ErrWriterClosed = fmt.Errorf("Writer closed")
+
+	// ErrReaderClosed return when reading from closed reader
+	ErrReaderClosed = fmt.Errorf("Reader closed")
+
+	// ErrResponseTooLarge return when response body exceeds configured max response size
+	ErrResponseTooLarge = fmt.Errorf("Response body too large")
+
+	// ErrClusterFull return when cluster has no free/writable volume left for assigning
+	ErrClusterFull = fmt.Errorf("Cluster has no free volume")
+
+	// ErrVolumeReadOnly return when writing to a volume which turned read only, e.g. because of being full
+	ErrVolumeReadOnly = fmt.Errorf("Volume is read only")
 )
 
+// maxReassign is the max number of re-assigning when uploading to a read only volume.
+const maxReassign = 3
+
 const (
 	// ParamCollection http param to specify collection which files belong. According to SeaweedFS API.
 	ParamCollection = "collection"
@@ -85,62 +105,143 @@ const (
 	// ParamUnmountVolume           = "volume"
 )
 
-// Seaweed client containing almost features/operations to interact with SeaweedFS
+// Seaweed client containing almost features/operations to interact with SeaweedFS.
+// Seaweed is safe for concurrent use by multiple goroutines, and is intended to be shared process-wide.
 type Seaweed struct {
-	master    *url.URL
+	master    atomic.Value // *url.URL
+	filersMu  sync.RWMutex
 	filers    []*Filer
 	chunkSize int64
 	client    *httpClient
 	workers   *workerpool.Pool
+	opts      *options
+	hedger    *hedger
+	locality  *locality
+	lookuper  Lookuper
+
+	capabilities capabilityCache
+
+	stopDiscovery chan struct{}
+	closeOnce     sync.Once
+	closeErr      error
 }
 
 // NewSeaweed create new seaweed client. Master url must be a valid uri (which includes scheme).
-func NewSeaweed(masterURL string, filers []string, chunkSize int64, client *http.Client) (c *Seaweed, err error) {
-	u, err := parseURI(masterURL)
-	if err != nil {
-		return
+// Master url could be empty if master resolver is configured (see WithMasterResolver).
+func NewSeaweed(masterURL string, filers []string, chunkSize int64, client *http.Client, opts ...Option) (c *Seaweed, err error) {
+	o := newOptions(opts)
+
+	var u *url.URL
+	if masterURL != "" || o.masterResolver == nil {
+		if u, err = parseURI(masterURL); err != nil {
+			return
+		}
 	}
 
 	c = &Seaweed{
-		master:    u,
-		client:    newHTTPClient(client),
+		client:    newHTTPClient(client, o),
 		chunkSize: chunkSize,
+		opts:      o,
+	}
+	if u != nil {
+		c.master.Store(u)
+	}
+	if o.hedgePercentile > 0 {
+		c.hedger = newHedger(o.hedgePercentile, o.hedgeMinDelay)
+	}
+	if o.dataCenter != "" {
+		c.locality = newLocality(o.dataCenter, o.rack, c.Status)
+	}
+	switch {
+	case o.lookuper != nil:
+		c.lookuper = o.lookuper
+	case o.transport != nil:
+		c.lookuper = o.transport
+	default:
+		c.lookuper = &masterLookuper{c: c}
 	}
 
-	if len(filers) > 0 {
-		c.filers = make([]*Filer, 0, len(filers))
-		for i := range filers {
-			var filer *Filer
-			filer, err = newFiler(filers[i], c.client)
-			if err != nil {
-				_ = c.Close()
-				return
-			}
-			c.filers = append(c.filers, filer)
-		}
+	if err = c.setFilers(filers); err != nil {
+		_ = c.Close()
+		return
 	}
 
 	// start underlying workers
 	c.workers = createWorkerPool()
 	c.workers.Start()
 
+	if err = c.startDiscovery(); err != nil {
+		_ = c.Close()
+		return
+	}
+
 	return
 }
 
-// Close underlying daemons.
+// Close underlying daemons. Close is idempotent.
 func (c *Seaweed) Close() (err error) {
-	if c.workers != nil {
-		c.workers.Stop()
+	c.closeOnce.Do(func() {
+		if c.stopDiscovery != nil {
+			close(c.stopDiscovery)
+		}
+		if c.workers != nil {
+			c.workers.Stop()
+		}
+		if c.client != nil {
+			c.closeErr = c.client.Close()
+		}
+	})
+	return c.closeErr
+}
+
+// Filers returns initialized filer(s). Returned slice is a copy, which is not updated by discovery.
+func (c *Seaweed) Filers() []*Filer {
+	c.filersMu.RLock()
+	defer c.filersMu.RUnlock()
+	return append([]*Filer(nil), c.filers...)
+}
+
+// setFilers replaces filers with given urls, keeping already initialized ones.
+func (c *Seaweed) setFilers(urls []string) (err error) {
+	c.filersMu.Lock()
+	defer c.filersMu.Unlock()
+
+	existing := make(map[string]*Filer, len(c.filers))
+	for _, filer := range c.filers {
+		existing[filer.base.String()] = filer
 	}
-	if c.client != nil {
-		err = c.client.Close()
+
+	var filers []*Filer
+	if len(urls) > 0 {
+		filers = make([]*Filer, 0, len(urls))
+		for i := range urls {
+			var filer *Filer
+			if filer, err = newFiler(urls[i], c.client); err != nil {
+				return
+			}
+			if old, ok := existing[filer.base.String()]; ok {
+				filer = old
+			}
+			filers = append(filers, filer)
+		}
 	}
+
+	c.filers = filers
 	return
 }
 
-// Filers returns initialized filer(s).
-func (c *Seaweed) Filers() []*Filer {
-	return c.filers
+// masterURL returns current master url.
+func (c *Seaweed) masterURL() url.URL {
+	u, _ := c.master.Load().(*url.URL)
+	if u == nil {
+		return url.URL{Scheme: "http"}
+	}
+	return *u
+}
+
+// BreakerStates returns circuit breaker state per host. Returns nil if circuit breaker is not enabled.
+func (c *Seaweed) BreakerStates() map[string]BreakerState {
+	return c.client.breakerStates()
 }
 
 // Grow pre-Allocate Volumes.
@@ -160,69 +261,103 @@ func (c *Seaweed) Grow(count int, collection, replication, dataCenter string) er
 
 // GrowArgs pre-Allocate volumes with args.
 func (c *Seaweed) GrowArgs(args url.Values) (err error) {
-	_, _, err = c.client.get(encodeURI(*c.master, "/vol/grow", args), nil)
+	_, _, err = c.client.get(encodeURI(c.masterURL(), "/vol/grow", args), nil)
 	return
 }
 
-// Lookup volume ID.
+// Lookup volume ID, through configured Lookuper (see WithLookuper), master by default.
 func (c *Seaweed) Lookup(volID string, args url.Values) (result *LookupResult, err error) {
-	result, err = c.doLookup(volID, args)
+	return c.LookupContext(context.Background(), volID, args)
+}
+
+// LookupContext is like Lookup, passing ctx on to Lookuper.
+func (c *Seaweed) LookupContext(ctx context.Context, volID string, args url.Values) (result *LookupResult, err error) {
+	locations, err := c.lookuper.Lookup(ctx, volID, args)
+	if err == nil {
+		// erasure coded volumes are reported once per shard
+		result = &LookupResult{VolumeLocations: locations.Unique()}
+	}
 	return
 }
 
-func (c *Seaweed) doLookup(volID string, args url.Values) (result *LookupResult, err error) {
-	args = normalize(args, "", "")
-	args.Set(ParamLookupVolumeID, volID)
+// LookupServerByFileID lookup server by file id.
+func (c *Seaweed) LookupServerByFileID(fileID string, args url.Values, readonly bool) (server string, err error) {
+	return c.lookupServerByFileID(context.Background(), fileID, args, readonly)
+}
 
-	jsonBlob, _, err := c.client.get(encodeURI(*c.master, "/dir/lookup", args), nil)
+func (c *Seaweed) lookupServerByFileID(ctx context.Context, fileID string, args url.Values, readonly bool) (server string, err error) {
+	locations, err := c.lookupFileLocations(ctx, fileID, args)
 	if err == nil {
-		result = &LookupResult{}
-		if err = json.Unmarshal(jsonBlob, result); err == nil {
-			if result.Error != "" {
-				err = errors.New(result.Error)
-			}
+		if readonly {
+			server = c.readOrder(locations).Head().PublicURL
+		} else {
+			server = locations.Head().URL
 		}
 	}
-
 	return
 }
 
-// LookupServerByFileID lookup server by file id.
-func (c *Seaweed) LookupServerByFileID(fileID string, args url.Values, readonly bool) (server string, err error) {
-	var parts []string
-	if strings.Contains(fileID, ",") {
-		parts = strings.Split(fileID, ",")
-	} else {
-		parts = strings.Split(fileID, "/")
+func (c *Seaweed) lookupFileLocations(ctx context.Context, fileID string, args url.Values) (locations VolumeLocations, err error) {
+	volID, err := parseVolumeID(fileID)
+	if err != nil {
+		return
 	}
 
-	if len(parts) != 2 { // wrong file id format
-		return "", errors.New("Invalid fileID " + fileID)
+	lookup, err := c.LookupContext(ctx, volID, args)
+	if err == nil {
+		if locations = lookup.VolumeLocations; len(locations) == 0 {
+			err = ErrFileNotFound
+		}
 	}
+	return
+}
 
-	lookup, lookupError := c.Lookup(parts[0], args)
-	if lookupError != nil {
-		err = lookupError
-	} else if len(lookup.VolumeLocations) == 0 {
-		err = ErrFileNotFound
+// fetchFromReplicas tries replica locations of file in random order until one of them responses.
+// Reads of erasure coded volumes could fail on some shard holders (e.g. not enough shards reachable to reconstruct),
+// so failing over to other locations is essential. If hedging is enabled, next location is also tried
+// when current one is slower than usual.
+func (c *Seaweed) fetchFromReplicas(ctx context.Context, method, fileID string, args url.Values, header http.Header) (result *DownloadResult, err error) {
+	locations, err := c.lookupFileLocations(ctx, fileID, args)
+	if err != nil {
+		return
 	}
+	locations = c.readOrder(locations)
 
-	if err == nil {
-		if readonly {
-			server = lookup.VolumeLocations.RandomPickForRead().PublicURL
+	var notFound int32
+	v, err := c.hedger.race(ctx, len(locations), func(ctx context.Context, attempt int) (interface{}, error) {
+		base := c.masterURL()
+		base.Host = locations[attempt].PublicURL
+
+		r, err := c.client.fetchContext(ctx, method, encodeURI(base, fileID, nil), header)
+		if errors.Is(err, ErrFileNotFound) {
+			atomic.AddInt32(&notFound, 1)
+		}
+		return r, err
+	}, func(v interface{}, cancel context.CancelFunc) {
+		// release request context once body is consumed
+		if r := v.(*DownloadResult); r.Body != nil {
+			r.Body.(*downloadBody).cancel = cancel
 		} else {
-			server = lookup.VolumeLocations.Head().URL
+			cancel()
 		}
-	}
+	}, func(v interface{}) {
+		_ = v.(*DownloadResult).Close()
+	})
 
-	return
+	if err == nil {
+		return v.(*DownloadResult), nil
+	}
+	if int(notFound) == len(locations) {
+		return nil, err
+	}
+	return nil, fmt.Errorf("Read %s failed on all %d locations, last error: %w", fileID, len(locations), err)
 }
 
 // LookupFileID lookup file by id.
 func (c *Seaweed) LookupFileID(fileID string, args url.Values, readonly bool) (fullURL string, err error) {
 	u, err := c.LookupServerByFileID(fileID, args, readonly)
 	if err == nil {
-		base := *c.master
+		base := c.masterURL()
 		base.Host = u
 		base.Path = fileID
 		fullURL = base.String()
@@ -235,41 +370,41 @@ func (c *Seaweed) GC(threshold float64) (err error) {
 	args := url.Values{
 		"garbageThreshold": []string{strconv.FormatFloat(threshold, 'f', -1, 64)},
 	}
-	_, _, err = c.client.get(encodeURI(*c.master, "/vol/vacuum", args), nil)
+	_, _, err = c.client.get(encodeURI(c.masterURL(), "/vol/vacuum", args), nil)
 	return
 }
 
 // Status check System Status.
 func (c *Seaweed) Status() (result *SystemStatus, err error) {
-	data, _, err := c.client.get(encodeURI(*c.master, "/dir/status", nil), nil)
-	if err == nil {
-		result = &SystemStatus{}
-		err = json.Unmarshal(data, result)
+	result = &SystemStatus{}
+	if _, err = c.client.getJSON(encodeURI(c.masterURL(), "/dir/status", nil), nil, result); err != nil {
+		result = nil
 	}
 	return
 }
 
 // ClusterStatus get cluster status.
 func (c *Seaweed) ClusterStatus() (result *ClusterStatus, err error) {
-	data, _, err := c.client.get(encodeURI(*c.master, "/cluster/status", nil), nil)
-	if err == nil {
-		result = &ClusterStatus{}
-		err = json.Unmarshal(data, result)
+	result = &ClusterStatus{}
+	if _, err = c.client.getJSON(encodeURI(c.masterURL(), "/cluster/status", nil), nil, result); err != nil {
+		result = nil
 	}
 	return
 }
 
 // Assign do assign api.
 func (c *Seaweed) Assign(args url.Values) (result *AssignResult, err error) {
-	jsonBlob, _, err := c.client.get(encodeURI(*c.master, "/dir/assign", args), nil)
-	if err == nil {
+	if t := c.opts.transport; t != nil {
+		result, err = t.Assign(context.Background(), args)
+	} else {
 		result = &AssignResult{}
-		if err = json.Unmarshal(jsonBlob, result); err != nil {
-			err = fmt.Errorf("/dir/assign result JSON unmarshal error:%v, json:%s", err, string(jsonBlob))
-		} else if result.Count == 0 {
-			err = errors.New(result.Error)
+		if _, err = c.client.getNegotiated(context.Background(), encodeURI(c.masterURL(), "/dir/assign", args), result); err != nil {
+			err = fmt.Errorf("/dir/assign result decode error:%w", err)
 		}
 	}
+	if err == nil && result.Count == 0 {
+		err = writeError(errors.New(result.Error))
+	}
 
 	return
 }
@@ -286,46 +421,98 @@ func (c *Seaweed) Submit(filePath string, collection, ttl string) (result *Submi
 
 // SubmitFilePart directly to master.
 func (c *Seaweed) SubmitFilePart(f *FilePart, args url.Values) (result *SubmitResult, err error) {
-	data, _, err := c.client.upload(encodeURI(*c.master, "/submit", args), f.FileName, f.Reader, f.MimeType, nil)
-	if err == nil {
-		result = &SubmitResult{}
-		err = json.Unmarshal(data, result)
+	quotaDone, err := c.client.reserveQuota(args.Get(ParamCollection), f.FileSize)
+	if err != nil {
+		return
+	}
+
+	result = &SubmitResult{}
+	defer func() {
+		var written int64
+		if result != nil {
+			written = result.Size
+		}
+		quotaDone(written, err)
+	}()
+
+	if _, err = c.client.upload(encodeURI(c.masterURL(), "/submit", args), f.FileName, f.Reader, f.FileSize, f.MimeType, nil, result); err != nil {
+		result = nil
 	}
 	return
 }
 
 // Upload file by reader.
-func (c *Seaweed) Upload(fileReader io.Reader, fileName string, size int64, collection, ttl string) (fp *FilePart, err error) {
-	fp = NewFilePartFromReader(ioutil.NopCloser(fileReader), fileName, size)
+func (c *Seaweed) Upload(fileReader io.Reader, fileName string, size int64, collection, ttl string, opts ...UploadOption) (fp *FilePart, err error) {
+	fp = NewFilePartFromReader(nopCloser(fileReader), fileName, size)
 	fp.Collection, fp.TTL = collection, ttl
-	_, err = c.UploadFilePart(fp, nil)
+	_, err = c.UploadFilePart(fp, nil, opts...)
 	return
 }
 
 // UploadFile with full file dir/path.
-func (c *Seaweed) UploadFile(filePath string, collection, ttl string) (cm *ChunkManifest, fp *FilePart, err error) {
+func (c *Seaweed) UploadFile(filePath string, collection, ttl string, opts ...UploadOption) (cm *ChunkManifest, fp *FilePart, err error) {
 	fp, err = NewFilePart(filePath)
 	if err == nil {
 		fp.Collection, fp.TTL = collection, ttl
-		cm, err = c.UploadFilePart(fp, nil)
+		cm, err = c.UploadFilePart(fp, nil, opts...)
 		_ = fp.Close()
 	}
 	return
 }
 
 // UploadFilePart uploads a file part.
-func (c *Seaweed) UploadFilePart(f *FilePart, extraMetadata map[string]string) (cm *ChunkManifest, err error) {
-	if f.FileID == "" {
+func (c *Seaweed) UploadFilePart(f *FilePart, extraMetadata map[string]string, opts ...UploadOption) (cm *ChunkManifest, err error) {
+	o := newUploadOptions(opts)
+
+	// validate before assigning. Chunks are sent as octet streams, so chunked upload is validated here only:
+	// mime type is resolved like for a single part upload, and sequentially read content is guarded
+	ra, concurrent := f.Reader.(io.ReaderAt)
+	concurrent = concurrent && c.client.opts.chunkConcurrency > 1
+
+	var content io.Reader = f.Reader
+	mtype := f.MimeType
+	if err = c.client.checkUploadSize(f.FileName, f.FileSize); err != nil {
+		return
+	}
+	if c.chunkSize > 0 && f.FileSize > c.chunkSize {
+		if concurrent {
+			mtype, _, err = resolveMimeType(f.FileName, mtype, io.NewSectionReader(ra, 0, f.FileSize))
+		} else {
+			mtype, content, err = resolveMimeType(f.FileName, mtype, content)
+			content = &uploadGuard{r: content, filename: f.FileName, expected: f.FileSize, limit: c.client.opts.maxUploadSize}
+		}
+		if err != nil {
+			return
+		}
+		if err = c.client.checkMimeType(f.FileName, mtype); err != nil {
+			return
+		}
+	}
+
+	quotaDone, err := c.client.reserveQuota(f.Collection, f.FileSize)
+	if err != nil {
+		return
+	}
+	defer func() {
+		var written int64
+		if f.Result != nil {
+			written = f.Result.Size
+		}
+		quotaDone(written, err)
+	}()
+
+	assigned := f.FileID == ""
+	if assigned {
 		var res *AssignResult
-		res, err = c.Assign(normalize(nil, f.Collection, f.TTL))
+		res, err = c.Assign(assignArgs(f))
 		if err != nil {
 			return
 		}
-		f.Server, f.FileID = res.URL, res.FileID
+		f.Server, f.FileID = c.client.pickUploadTarget(res), res.FileID
 	}
 
 	if f.Server == "" {
-		if f.Server, err = c.LookupServerByFileID(f.FileID, normalize(nil, f.Collection, ""), false); err != nil {
+		if f.Server, err = c.lookupServerByFileID(o.ctx, f.FileID, normalize(nil, f.Collection, ""), false); err != nil {
 			return
 		}
 	}
@@ -333,30 +520,37 @@ func (c *Seaweed) UploadFilePart(f *FilePart, extraMetadata map[string]string) (
 	baseName := path.Base(f.FileName)
 
 	if c.chunkSize > 0 && f.FileSize > c.chunkSize {
-		chunks := f.FileSize/c.chunkSize + 1
-
 		cm = &ChunkManifest{
-			Name:   baseName,
-			Size:   f.FileSize,
-			Mime:   f.MimeType,
-			Chunks: make([]*ChunkInfo, chunks),
+			Name: baseName,
+			Size: f.FileSize,
+			Mime: mtype,
 		}
 
-		for i := int64(0); i < chunks; i++ {
-			_, id, count, e := c.uploadChunk(f, baseName+"_"+strconv.FormatInt(i+1, 10))
-			if e != nil { // delete all uploaded chunks
+		if concurrent {
+			if err = c.uploadChunksAt(o.ctx, f, ra, cm); err != nil { // delete all uploaded chunks
 				_ = c.DeleteChunks(cm, normalize(nil, f.Collection, ""))
-				return nil, e
+				return nil, err
 			}
-
-			cm.Chunks[i] = &ChunkInfo{
-				Offset: i * c.chunkSize,
-				Size:   int64(count),
-				Fid:    id,
+		} else {
+			chunks := f.FileSize/c.chunkSize + 1
+			cm.Chunks = make([]*ChunkInfo, chunks)
+
+			for i := int64(0); i < chunks; i++ {
+				_, id, count, e := c.uploadChunk(o.ctx, f, io.LimitReader(content, c.chunkSize), baseName+"_"+strconv.FormatInt(i+1, 10), nil)
+				if e != nil { // delete all uploaded chunks
+					_ = c.DeleteChunks(cm, normalize(nil, f.Collection, ""))
+					return nil, e
+				}
+
+				cm.Chunks[i] = &ChunkInfo{
+					Offset: i * c.chunkSize,
+					Size:   int64(count),
+					Fid:    id,
+				}
 			}
 		}
 
-		if err = c.uploadManifest(f, cm); err != nil { // delete all uploaded chunks
+		if err = c.uploadManifest(o.ctx, f, cm); err != nil { // delete all uploaded chunks
 			_ = c.DeleteChunks(cm, normalize(nil, f.Collection, ""))
 		}
 	} else {
@@ -365,10 +559,31 @@ func (c *Seaweed) UploadFilePart(f *FilePart, extraMetadata map[string]string) (
 			args.Set("ts", strconv.FormatInt(f.ModTime, 10))
 		}
 
-		base := *c.master
-		base.Host = f.Server
+		var reassign func() error
+		if assigned {
+			reassign = func() (e error) {
+				var res *AssignResult
+				if res, e = c.Assign(assignArgs(f)); e == nil {
+					f.Server, f.FileID = c.client.pickUploadTarget(res), res.FileID
+				}
+				return
+			}
+		}
 
-		_, _, err = c.client.upload(encodeURI(base, f.FileID, args), baseName, f.Reader, f.MimeType, extraMetadata)
+		err = retryReadOnly(f.Reader, reassign, func() (e error) {
+			base := c.masterURL()
+			base.Host = f.Server
+
+			result := &UploadResult{}
+			if _, e = c.client.uploadContext(o.ctx, encodeURI(base, f.FileID, args), baseName, withBodyFactory(f.Reader, o.getBody), f.FileSize, f.MimeType, metadataHeader(extraMetadata), result); e == nil {
+				result.FileID, f.Result = f.FileID, result
+			}
+			return
+		})
+	}
+
+	if err == nil && o.replicationAck && !c.opts.dryRun { // nothing was written to check
+		err = c.verifyReplication(o.ctx, f)
 	}
 
 	return
@@ -439,7 +654,7 @@ func (c *Seaweed) uploadTask(file *FilePart) *workerpool.Task {
 
 // Replace file content with new one.
 func (c *Seaweed) Replace(fileID string, newContent io.Reader, fileName string, size int64, collection, ttl string, deleteFirst bool) (err error) {
-	fp := NewFilePartFromReader(ioutil.NopCloser(newContent), fileName, size)
+	fp := NewFilePartFromReader(nopCloser(newContent), fileName, size)
 	fp.Collection, fp.TTL = collection, ttl
 	fp.FileID = fileID
 	err = c.ReplaceFilePart(fp, deleteFirst)
@@ -467,34 +682,42 @@ func (c *Seaweed) ReplaceFilePart(f *FilePart, deleteFirst bool) (err error) {
 	return
 }
 
-func (c *Seaweed) uploadChunk(f *FilePart, filename string) (assignResult *AssignResult, fileID string, size int64, err error) {
+// uploadChunk uploads content of r as a chunk of f, to file id reserved by assigned if any, assigning otherwise.
+func (c *Seaweed) uploadChunk(ctx context.Context, f *FilePart, r io.Reader, filename string, assigned *AssignResult) (assignResult *AssignResult, fileID string, size int64, err error) {
 	// Assign first to get file id and url for uploading
-	assignResult, err = c.Assign(normalize(nil, f.Collection, f.TTL))
+	assign := func() (e error) {
+		assignResult, e = c.Assign(assignArgs(f))
+		return
+	}
+
+	if assigned != nil {
+		assignResult = assigned
+	} else {
+		err = assign()
+	}
+
 	if err == nil {
-		fileID = assignResult.FileID
-
-		base := *c.master
-		base.Host = assignResult.URL
-
-		// do upload
-		var v []byte
-		v, _, err = c.client.upload(
-			encodeURI(base, assignResult.FileID, nil),
-			filename, io.LimitReader(f.Reader, c.chunkSize),
-			"application/octet-stream", nil)
-		if err == nil {
-			// parsing response data
+		err = retryReadOnly(r, assign, func() (e error) {
+			base := c.masterURL()
+			base.Host = c.client.pickUploadTarget(assignResult)
+
+			// do upload
 			uploadResult := UploadResult{}
-			if err = json.Unmarshal(v, &uploadResult); err == nil {
-				size = uploadResult.Size
+			_, e = c.client.uploadContext(ctx,
+				encodeURI(base, assignResult.FileID, nil),
+				filename, r, -1,
+				"application/octet-stream", nil, &uploadResult)
+			if e == nil {
+				fileID, size = assignResult.FileID, uploadResult.Size
 			}
-		}
+			return
+		})
 	}
 
 	return
 }
 
-func (c *Seaweed) uploadManifest(f *FilePart, manifest *ChunkManifest) (err error) {
+func (c *Seaweed) uploadManifest(ctx context.Context, f *FilePart, manifest *ChunkManifest) (err error) {
 	buf, err := manifest.Marshal()
 	if err == nil {
 		bufReader := bytes.NewReader(buf)
@@ -505,59 +728,83 @@ func (c *Seaweed) uploadManifest(f *FilePart, manifest *ChunkManifest) (err erro
 		}
 		args.Set("cm", "true")
 
-		base := *c.master
+		base := c.masterURL()
 		base.Host = f.Server
 
-		_, _, err = c.client.upload(encodeURI(base, f.FileID, args), manifest.Name, bufReader, "application/json", nil)
+		result := &UploadResult{}
+		if _, err = c.client.uploadContext(ctx, encodeURI(base, f.FileID, args), manifest.Name, bufReader, -1, "application/json", nil, result); err == nil {
+			result.FileID, result.Size, f.Result = f.FileID, f.FileSize, result
+		}
 	}
 	return
 }
 
+// Fetch file by id. Returned result contains file metadata and its body, which must be closed by caller.
+func (c *Seaweed) Fetch(fileID string, args url.Values, header http.Header) (result *DownloadResult, err error) {
+	return c.FetchContext(context.Background(), fileID, args, header)
+}
+
+// FetchContext is like Fetch, aborting lookup and reading once ctx is done.
+func (c *Seaweed) FetchContext(ctx context.Context, fileID string, args url.Values, header http.Header) (result *DownloadResult, err error) {
+	result, err = c.fetchFromReplicas(ctx, http.MethodGet, fileID, args, header)
+	return
+}
+
 // Download file by id.
 func (c *Seaweed) Download(fileID string, args url.Values, callback func(io.Reader) error) (fileName string, err error) {
-	fileURL, err := c.LookupFileID(fileID, args, true)
-	if err == nil {
-		fileName, err = c.client.download(fileURL, callback)
-	}
+	fileName, _, err = c.DownloadWithMetadata(fileID, args, callback)
 	return
 }
 
+// DownloadByReadCloser downloads file by id. Returned body must be closed by caller.
 func (c *Seaweed) DownloadByReadCloser(fileID string, args url.Values) (fileName string, size int64, md map[string]string, rc io.ReadCloser, err error) {
-	fileURL, err := c.LookupFileID(fileID, args, true)
+	result, err := c.Fetch(fileID, args, nil)
 	if err == nil {
-		fileName, size, md, rc, err = c.client.downloadByReadCloser(fileURL)
+		fileName, size, md, rc = result.Name, result.Size, result.Metadata(), result.Body
 	}
 	return
 }
 
+// DownloadByReadCloserWithHeader downloads file by id with custom request header. Returned body must be closed by caller.
 func (c *Seaweed) DownloadByReadCloserWithHeader(fileID string, rqHeader http.Header) (fileName string, size int64, rsHeader http.Header, rsBody io.ReadCloser, err error) {
-	fileURL, err := c.LookupFileID(fileID, nil, true)
+	result, err := c.Fetch(fileID, nil, rqHeader)
 	if err == nil {
-		fileName, size, rsHeader, rsBody, err = c.client.downloadByReadCloserWithHeader(fileURL, rqHeader)
+		fileName, size, rsHeader, rsBody = result.Name, result.Size, result.Header, result.Body
 	}
 	return
 }
 
+// DownloadByReadCloserWithHTTPRanges downloads part of file by id, according to http range header. Returned body must be closed by caller.
 func (c *Seaweed) DownloadByReadCloserWithHTTPRanges(fileID string, args url.Values, ranges string) (fileName string, size int64, md map[string]string, rc io.ReadCloser, err error) {
-	fileURL, err := c.LookupFileID(fileID, args, true)
+	var header http.Header
+	if len(ranges) > 0 {
+		header = http.Header{"Range": []string{ranges}}
+	}
+
+	result, err := c.Fetch(fileID, args, header)
 	if err == nil {
-		fileName, size, md, rc, err = c.client.downloadByReadCloserWithHTTPRange(fileURL, ranges)
+		fileName, size, md, rc = result.Name, result.Size, result.Metadata(), result.Body
 	}
 	return
 }
 
+// DownloadWithMetadata downloads file by id, returning its response header as metadata.
 func (c *Seaweed) DownloadWithMetadata(fileID string, args url.Values, callback func(io.Reader) error) (fileName string, md map[string]string, err error) {
-	fileURL, err := c.LookupFileID(fileID, args, true)
+	result, err := c.fetchFromReplicas(context.Background(), http.MethodGet, fileID, args, nil)
 	if err == nil {
-		fileName, md, err = c.client.downloadWithMetadata(fileURL, callback)
+		fileName, md = result.Name, result.Metadata()
+
+		// execute callback, then drain and close body
+		err = c.client.finishDownload(result, callback(result.Body))
 	}
 	return
 }
 
+// Preview file metadata by id without downloading its content.
 func (c *Seaweed) Preview(fileID string, args url.Values) (fileName string, size int64, md map[string]string, err error) {
-	fileURL, err := c.LookupFileID(fileID, args, true)
+	result, err := c.fetchFromReplicas(context.Background(), http.MethodHead, fileID, args, nil)
 	if err == nil {
-		fileName, size, md, err = c.client.preview(fileURL)
+		fileName, size, md = result.Name, result.Size, result.Metadata()
 	}
 	return
 }
@@ -570,6 +817,9 @@ func (c *Seaweed) DeleteChunks(cm *ChunkManifest, args url.Values) (err error) {
 
 	tasks := make([]*workerpool.Task, 0, len(cm.Chunks))
 	for _, ci := range cm.Chunks {
+		if ci == nil { // not uploaded
+			continue
+		}
 		task := c.deleteFileTask(ci.Fid, args)
 		c.workers.Do(task)
 		tasks = append(tasks, task)
@@ -8,15 +8,20 @@
 package goseaweedfs
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -197,10 +202,31 @@ func TestFiler(t *testing.T) {
 	require.Nil(t, err)
 	require.NotZero(t, buf.Len())
 
+	// stat and check existence
+	fi, err := filer.Stat("/js/test.txt")
+	require.Nil(t, err)
+	require.EqualValues(t, buf.Len(), fi.Size())
+	require.False(t, fi.IsDir())
+
+	exists, err := filer.Exists("/js/test.txt")
+	require.Nil(t, err)
+	require.True(t, exists)
+
+	// exclusive create must not overwrite
+	_, err = filer.UploadFile(SmallFile, "/js/test.txt", "", "", WithExclusiveCreate())
+	require.Equal(t, ErrAlreadyExists, err)
+
 	// try to delete this file
 	err = filer.Delete("/js/test.txt", nil)
 	require.Nil(t, err)
 
+	exists, err = filer.Exists("/js/test.txt")
+	require.Nil(t, err)
+	require.False(t, exists)
+
+	_, err = filer.Stat("/js/test.txt")
+	require.Equal(t, ErrFileNotFound, err)
+
 	// test with non prefix
 	_, err = filer.UploadFile(SmallFile, "js/test1.jsx", "", "")
 	require.Nil(t, err)
@@ -282,3 +308,162 @@ func loadChunkManifest(buffer []byte, isGzipped bool) (*ChunkManifest, error) {
 
 	return &cm, nil
 }
+
+func TestFilerBatchWriter(t *testing.T) {
+	filer := sw.filers[0]
+
+	w := filer.NewBatchWriter("/batch", BatchWriterOption{
+		MaxBatchSize: 64,
+		NameFunc: func(seq uint64) string {
+			return fmt.Sprintf("%d.ndjson", seq)
+		},
+	})
+	for i := 0; i < 10; i++ {
+		require.Nil(t, w.WriteJSON(map[string]int{"i": i}))
+	}
+	require.Nil(t, w.Close())
+	require.Equal(t, ErrBatchWriterClosed, w.Write([]byte("x")))
+
+	r, err := filer.OpenNDJSON("/batch/1.ndjson", 0)
+	require.Nil(t, err)
+	var v map[string]int
+	require.Nil(t, r.Decode(&v))
+	require.Equal(t, 0, v["i"])
+	require.Nil(t, r.Close())
+
+	require.Nil(t, filer.Delete("/batch", map[string][]string{"recursive": {"true"}}))
+}
+
+func TestValidate(t *testing.T) {
+	report, err := sw.Validate(context.Background())
+	require.Nil(t, err)
+	require.True(t, report.Master.Reachable)
+	require.NotEmpty(t, report.Master.Version)
+}
+
+func TestUploadWithReplicationAck(t *testing.T) {
+	_, fp, err := sw.UploadFile(SmallFile, "", "", WithReplicationAck())
+	require.Nil(t, err)
+	require.Nil(t, sw.DeleteFile(fp.FileID, nil))
+}
+
+func TestFilerFileSystem(t *testing.T) {
+	filer := sw.filers[0]
+
+	_, err := filer.UploadFile(SmallFile, "/fs/test.txt", "", "")
+	require.Nil(t, err)
+
+	server := httptest.NewServer(filer.FileSystem("/fs"))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/test.txt", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	data, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+
+	fh, err := os.Open(SmallFile)
+	require.Nil(t, err)
+	allContent, _ := ioutil.ReadAll(fh)
+	require.Nil(t, fh.Close())
+	require.EqualValues(t, allContent[2:6], data)
+
+	entries, err := filer.ListDir("/fs")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(entries))
+
+	resp, err = http.Get(server.URL + "/not-found.txt")
+	require.Nil(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	require.Nil(t, filer.Delete("/fs", map[string][]string{"recursive": {"true"}}))
+}
+
+func TestFilerDownloadArchive(t *testing.T) {
+	filer := sw.filers[0]
+
+	_, err := filer.UploadFile(SmallFile, "/archive/a.txt", "", "")
+	require.Nil(t, err)
+	_, err = filer.UploadFile(SmallFile, "/archive/sub/b.txt", "", "")
+	require.Nil(t, err)
+
+	var buf bytes.Buffer
+	require.Nil(t, filer.DownloadArchive("/archive", ArchiveTar, &buf))
+
+	var names []string
+	tr := tar.NewReader(&buf)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.Nil(t, err)
+		names = append(names, h.Name)
+	}
+	require.Equal(t, []string{"a.txt", "sub/", "sub/b.txt"}, names)
+
+	buf.Reset()
+	require.Nil(t, filer.DownloadArchive("/archive", ArchiveZip, &buf))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.Nil(t, err)
+	require.Equal(t, 3, len(zr.File))
+
+	require.Equal(t, ErrUnsupportedArchiveFormat, filer.DownloadArchive("/archive", "rar", &buf))
+
+	// re-import zip into another dir
+	require.Nil(t, filer.UploadArchive(bytes.NewReader(buf.Bytes()), ArchiveZip, "/imported", 2))
+	fi, err := filer.Stat("/imported/sub/b.txt")
+	require.Nil(t, err)
+	require.NotZero(t, fi.Size())
+
+	require.Nil(t, filer.Delete("/archive", map[string][]string{"recursive": {"true"}}))
+	require.Nil(t, filer.Delete("/imported", map[string][]string{"recursive": {"true"}}))
+}
+
+func TestUsage(t *testing.T) {
+	usage, err := sw.CollectionUsage(context.Background())
+	require.Nil(t, err)
+	require.NotEmpty(t, usage)
+
+	filer := sw.filers[0]
+	_, err = filer.UploadFile(SmallFile, "/usage/a.txt", "", "")
+	require.Nil(t, err)
+	_, err = filer.UploadFile(SmallFile, "/usage/sub/b.txt", "", "")
+	require.Nil(t, err)
+
+	du, err := filer.Usage("/usage")
+	require.Nil(t, err)
+	require.EqualValues(t, 2, du.Files)
+	require.EqualValues(t, 1, du.Dirs)
+	require.False(t, du.Exceeds(1<<20))
+
+	require.Nil(t, filer.Delete("/usage", map[string][]string{"recursive": {"true"}}))
+}
+
+func TestMigrate(t *testing.T) {
+	_, fp, err := sw.UploadFile(SmallFile, "", "")
+	require.Nil(t, err)
+
+	result, err := sw.Migrate(fp.FileID, "migrated", MigrateOption{DeleteSource: true})
+	require.Nil(t, err)
+	require.NotEqual(t, fp.FileID, result.Target)
+
+	_, err = sw.Fetch(fp.FileID, nil, nil)
+	require.NotNil(t, err)
+	require.Nil(t, sw.DeleteFile(result.Target, nil))
+}
+
+func TestUploadTemporary(t *testing.T) {
+	link, err := sw.UploadTemporary(bytes.NewReader([]byte("share me")), "share.txt", 8, "", 24*time.Hour)
+	require.Nil(t, err)
+	require.True(t, strings.HasSuffix(link.URL, link.FileID+"/share.txt"))
+	require.True(t, link.ExpiresAt.After(time.Now().Add(23*time.Hour)))
+
+	resp, err := http.Get(link.URL)
+	require.Nil(t, err)
+	drainAndClose(resp.Body)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,134 @@
+package goseaweedfs
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// snapshotTimeFormat names snapshot directories, sorting chronologically.
+const snapshotTimeFormat = "20060102T150405.000Z"
+
+// SnapshotEntry an entry captured by snapshot.
+type SnapshotEntry struct {
+	// Path relative to snapshot root.
+	Path  string
+	IsDir bool
+	Size  int64
+	Mtime time.Time
+
+	// Modified reports entry changed (or vanished) while snapshot was taken, so captured content may be
+	// either the old or the new one.
+	Modified bool
+}
+
+// SnapshotManifest describes what a snapshot captured.
+type SnapshotManifest struct {
+	Source     string
+	Target     string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Entries    []*SnapshotEntry
+}
+
+// Consistent reports whether no entry was modified while snapshot was taken.
+func (m *SnapshotManifest) Consistent() bool {
+	for _, e := range m.Entries {
+		if e.Modified {
+			return false
+		}
+	}
+	return true
+}
+
+// Snapshot copies subtree srcDir into a new timestamped directory under dstDir (e.g. dstDir/20060102T150405.000Z),
+// returning manifest of captured entries. Filer HTTP API has no server-side copy, so file content is streamed
+// through client without buffering; collection, replication and TTL of entries are kept. Files are re-stated
+// once copied: those modified concurrently (or listed after snapshot started) are flagged in manifest.
+// On error, manifest of entries captured so far is returned along with it.
+func (f *Filer) Snapshot(ctx context.Context, srcDir, dstDir string) (m *SnapshotManifest, err error) {
+	srcDir = path.Clean("/" + srcDir)
+
+	m = &SnapshotManifest{
+		Source:    srcDir,
+		StartedAt: time.Now().UTC(),
+	}
+	m.Target = path.Join("/", dstDir, m.StartedAt.Format(snapshotTimeFormat))
+
+	if err = f.Mkdir(m.Target); err != nil {
+		return
+	}
+
+	err = f.Walk(ctx, srcDir, func(p string, fi *FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == m.Target { // snapshot taken into source itself
+			return fs.SkipDir
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, srcDir), "/")
+		if rel == "" { // root
+			return nil
+		}
+
+		entry := &SnapshotEntry{Path: rel, IsDir: fi.IsDir(), Size: fi.Size(), Mtime: fi.ModTime()}
+		target := path.Join(m.Target, rel)
+		if entry.IsDir {
+			err = f.Mkdir(target)
+		} else if entry.Modified, err = f.copyEntry(p, target, fi); err == nil && !entry.Modified {
+			entry.Modified = fi.ModTime().After(m.StartedAt)
+		}
+		if err != nil {
+			return err
+		}
+
+		m.Entries = append(m.Entries, entry)
+		return nil
+	})
+
+	m.FinishedAt = time.Now().UTC()
+	return
+}
+
+// copyEntry streams content of src into dst, keeping its collection, replication, TTL, mime type and custom metadata.
+// Modified reports src changed or vanished while being copied, compared to fi.
+func (f *Filer) copyEntry(src, dst string, fi *FileInfo) (modified bool, err error) {
+	r, err := f.Fetch(src, nil, nil)
+	if err != nil {
+		return
+	}
+	defer func() { _ = r.Close() }()
+
+	args := normalize(nil, fi.Collection, "")
+	if fi.Replication != "" {
+		args.Set(ParamAssignReplication, fi.Replication)
+	}
+	if fi.TTLSec > 0 {
+		if ttl, _, e := FormatTTL(time.Duration(fi.TTLSec) * time.Second); e == nil {
+			args.Set(ParamTTL, ttl)
+		}
+	}
+
+	quotaDone, err := f.client.reserveQuota(fi.Collection, r.Size)
+	if err != nil {
+		return
+	}
+
+	uploaded := &FilerUploadResult{}
+	defer func() { quotaDone(uploaded.Size, err) }()
+
+	if _, err = f.client.upload(encodeURI(*f.base, dst, args), path.Base(dst), r.Body, r.Size, r.MimeType, metadataHeader(r.Tags), uploaded); err != nil {
+		return
+	}
+	if uploaded.Error != "" {
+		return false, fmt.Errorf("Copy %s to %s: %s", src, dst, uploaded.Error)
+	}
+
+	after, e := f.Stat(src)
+	modified = e != nil || !after.ModTime().Equal(fi.ModTime()) || after.Size() != fi.Size()
+	return
+}
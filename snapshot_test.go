@@ -0,0 +1,134 @@
+package goseaweedfs
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newMemFiler serves a minimal in-memory filer: metadata, listing, download, upload and mkdir.
+// Hook is called on every download, e.g. to modify entries concurrently.
+func newMemFiler(t *testing.T, files map[string]string, hook func(p string, entries map[string]*FileInfo, blobs map[string]string)) (*Filer, func() map[string]string) {
+	var mu sync.Mutex
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	entries := map[string]*FileInfo{"/": {FullPath: "/", FileMode: os.ModeDir | 0755}}
+	blobs := make(map[string]string)
+
+	var mkdir func(p string)
+	mkdir = func(p string) {
+		if _, ok := entries[p]; !ok {
+			entries[p] = &FileInfo{FullPath: p, FileMode: os.ModeDir | 0755, Mtime: mtime}
+			mkdir(path.Dir(p))
+		}
+	}
+	put := func(p, content string) {
+		mkdir(path.Dir(p))
+		entries[p] = &FileInfo{FullPath: p, FileMode: 0644, Mtime: mtime, FileSize: uint64(len(content)), Collection: "c1"}
+		blobs[p] = content
+	}
+	for p, content := range files {
+		put(p, content)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		p := path.Clean(r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/"):
+			mkdir(p)
+		case r.Method == http.MethodPost:
+			f, _, err := r.FormFile("file")
+			require.Nil(t, err)
+			data, _ := ioutil.ReadAll(f)
+			require.Equal(t, "c1", r.URL.Query().Get(ParamCollection))
+			put(p, string(data))
+			_ = json.NewEncoder(w).Encode(&FilerUploadResult{Name: path.Base(p), Size: int64(len(data))})
+		case entries[p] == nil:
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Query().Get("metadata") == "true":
+			_ = json.NewEncoder(w).Encode(entries[p])
+		case entries[p].IsDir():
+			listing := &FilerListing{Path: p}
+			for c, e := range entries {
+				if c != "/" && path.Dir(c) == p {
+					listing.Entries = append(listing.Entries, e)
+				}
+			}
+			sort.Slice(listing.Entries, func(i, j int) bool { return listing.Entries[i].FullPath < listing.Entries[j].FullPath })
+			_ = json.NewEncoder(w).Encode(listing)
+		default:
+			if hook != nil {
+				hook(p, entries, blobs)
+			}
+			_, _ = w.Write([]byte(blobs[p]))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = filer.Close() })
+
+	return filer, func() map[string]string {
+		mu.Lock()
+		defer mu.Unlock()
+		copied := make(map[string]string, len(blobs))
+		for k, v := range blobs {
+			copied[k] = v
+		}
+		return copied
+	}
+}
+
+func TestFilerSnapshot(t *testing.T) {
+	filer, blobs := newMemFiler(t, map[string]string{
+		"/src/a.txt":     "a",
+		"/src/sub/b.txt": "bb",
+		"/other/c.txt":   "c",
+	}, func(p string, entries map[string]*FileInfo, blobs map[string]string) {
+		if p == "/src/sub/b.txt" { // written concurrently
+			entries[p].Mtime = time.Now()
+		}
+	})
+
+	m, err := filer.Snapshot(context.Background(), "src", "/snapshots")
+	require.Nil(t, err)
+	require.Equal(t, "/src", m.Source)
+	require.True(t, strings.HasPrefix(m.Target, "/snapshots/"+m.StartedAt.Format("20060102T")))
+	require.False(t, m.FinishedAt.Before(m.StartedAt))
+
+	var captured []string
+	for _, e := range m.Entries {
+		captured = append(captured, e.Path)
+		require.Equal(t, e.Path == "sub/b.txt", e.Modified, e.Path)
+	}
+	require.Equal(t, []string{"a.txt", "sub", "sub/b.txt"}, captured)
+	require.False(t, m.Consistent())
+
+	copied := blobs()
+	require.Equal(t, "a", copied[m.Target+"/a.txt"])
+	require.Equal(t, "bb", copied[m.Target+"/sub/b.txt"])
+	require.Len(t, copied, 5)
+
+	// snapshot into source itself does not capture itself
+	m, err = filer.Snapshot(context.Background(), "/other", "/other/snapshots")
+	require.Nil(t, err)
+	require.Len(t, m.Entries, 2)
+	require.Equal(t, "snapshots", m.Entries[1].Path)
+
+	_, err = filer.Snapshot(context.Background(), "/missing", "/snapshots")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,197 @@
+package goseaweedfs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// copyBufferSize is size of buffers used to stream downloaded bodies, larger than io.Copy's default one.
+const copyBufferSize = 256 << 10
+
+var copyBufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, copyBufferSize)
+		return &buf
+	},
+}
+
+// downloadBody wraps response body, implementing io.WriterTo so io.Copy to a socket/file uses large buffers.
+type downloadBody struct {
+	io.ReadCloser
+
+	// cancel releases request context, if any, after body is closed.
+	cancel context.CancelFunc
+
+	// expected is Content-Length, -1 if unknown.
+	expected int64
+	// read counts bytes read so far, err keeps first read error other than EOF.
+	read int64
+	err  error
+}
+
+// Read from body.
+func (b *downloadBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if err != nil && err != io.EOF && b.err == nil {
+		b.err = err
+	}
+	return
+}
+
+// Close body.
+func (b *downloadBody) Close() (err error) {
+	err = b.ReadCloser.Close()
+	if b.cancel != nil {
+		b.cancel()
+	}
+	return
+}
+
+// WriteTo writes body to w until EOF.
+func (b *downloadBody) WriteTo(w io.Writer) (n int64, err error) {
+	buf := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(buf)
+
+	// hide ReaderFrom/WriterTo of both sides, so our buffer is used
+	return io.CopyBuffer(struct{ io.Writer }{w}, struct{ io.Reader }{b}, *buf)
+}
+
+// UploadWriter streams written content to filer. Upload is committed on Close.
+// UploadWriter implements io.ReaderFrom: io.Copy into it hands source reader directly to uploader,
+// without copying through intermediate buffers.
+type UploadWriter struct {
+	feeds    chan io.Reader
+	consumed chan error
+	cur      io.Reader
+
+	done   chan struct{}
+	result *FilerUploadResult
+	err    error
+	closed bool
+}
+
+// NewWriter creates upload writer to newPath.
+func (f *Filer) NewWriter(newPath, collection, ttl string, opts ...UploadOption) *UploadWriter {
+	return newUploadWriter(func(r io.Reader) (*FilerUploadResult, error) {
+		fp := NewFilePartFromReader(nopCloser(r), newPath, 0)
+		fp.Collection, fp.TTL = collection, ttl
+		return f.UploadFilePart(fp, newPath, opts...)
+	})
+}
+
+func newUploadWriter(upload func(io.Reader) (*FilerUploadResult, error)) *UploadWriter {
+	w := &UploadWriter{
+		feeds:    make(chan io.Reader),
+		consumed: make(chan error, 1),
+		done:     make(chan struct{}),
+	}
+
+	go func() {
+		w.result, w.err = upload(readerFunc(w.read))
+		close(w.done)
+	}()
+
+	return w
+}
+
+// read is called by uploader, pulling fed readers one by one.
+func (w *UploadWriter) read(p []byte) (n int, err error) {
+	for {
+		if w.cur == nil {
+			r, ok := <-w.feeds
+			if !ok {
+				return 0, io.EOF
+			}
+			w.cur = r
+		}
+
+		n, err = w.cur.Read(p)
+		if err == io.EOF {
+			w.cur, err = nil, nil
+			w.consumed <- nil
+			if n == 0 {
+				continue
+			}
+		} else if err != nil {
+			w.cur = nil
+			w.consumed <- err
+		}
+		return
+	}
+}
+
+func (w *UploadWriter) feed(r io.Reader) error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	select {
+	case w.feeds <- r:
+	case <-w.done:
+		return w.uploadErr()
+	}
+
+	select {
+	case err := <-w.consumed:
+		return err
+	case <-w.done:
+		return w.uploadErr()
+	}
+}
+
+func (w *UploadWriter) uploadErr() error {
+	if w.err != nil {
+		return w.err
+	}
+	return io.ErrClosedPipe
+}
+
+// Write p to upload stream.
+func (w *UploadWriter) Write(p []byte) (int, error) {
+	if err := w.feed(bytes.NewReader(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// ReadFrom streams r to upload until EOF.
+func (w *UploadWriter) ReadFrom(r io.Reader) (n int64, err error) {
+	cr := &countingReader{r: r}
+	err = w.feed(cr)
+	return cr.n, err
+}
+
+// Close commits upload and waits for its result.
+func (w *UploadWriter) Close() error {
+	if !w.closed {
+		w.closed = true
+		close(w.feeds)
+	}
+	<-w.done
+	return w.err
+}
+
+// Result of upload, available after Close.
+func (w *UploadWriter) Result() *FilerUploadResult {
+	return w.result
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (n int, err error) {
+	n, err = c.r.Read(p)
+	c.n += int64(n)
+	return
+}
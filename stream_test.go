@@ -0,0 +1,54 @@
+package goseaweedfs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadBodyWriteTo(t *testing.T) {
+	content := strings.Repeat("seaweed", copyBufferSize)
+	body := &downloadBody{ReadCloser: ioutil.NopCloser(strings.NewReader(content))}
+
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, body)
+	require.Nil(t, err)
+	require.EqualValues(t, len(content), n)
+	require.Equal(t, content, buf.String())
+}
+
+func TestUploadWriter(t *testing.T) {
+	var uploaded bytes.Buffer
+	w := newUploadWriter(func(r io.Reader) (*FilerUploadResult, error) {
+		n, err := io.Copy(&uploaded, r)
+		return &FilerUploadResult{Size: n}, err
+	})
+
+	_, err := w.Write([]byte("hello "))
+	require.Nil(t, err)
+	n, err := io.Copy(w, strings.NewReader("world"))
+	require.Nil(t, err)
+	require.EqualValues(t, 5, n)
+
+	require.Nil(t, w.Close())
+	require.Equal(t, "hello world", uploaded.String())
+	require.EqualValues(t, 11, w.Result().Size)
+
+	_, err = w.Write([]byte("x"))
+	require.Equal(t, ErrWriterClosed, err)
+}
+
+func TestUploadWriterFailure(t *testing.T) {
+	w := newUploadWriter(func(r io.Reader) (*FilerUploadResult, error) {
+		return nil, fmt.Errorf("Fake error")
+	})
+
+	_, err := w.Write([]byte("hello"))
+	require.EqualError(t, err, "Fake error")
+	require.EqualError(t, w.Close(), "Fake error")
+}
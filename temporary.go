@@ -0,0 +1,97 @@
+package goseaweedfs
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidTTL return when a duration can not be expressed as SeaweedFS TTL.
+var ErrInvalidTTL = fmt.Errorf("Invalid TTL")
+
+// ttlUnits SeaweedFS TTL units, from the biggest one.
+var ttlUnits = []struct {
+	suffix   string
+	duration time.Duration
+}{
+	{"y", 365 * 24 * time.Hour},
+	{"M", 30 * 24 * time.Hour},
+	{"w", 7 * 24 * time.Hour},
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+}
+
+// maxTTLCount is the max count of a TTL unit, which is stored in a byte.
+const maxTTLCount = 255
+
+// FormatTTL converts duration into SeaweedFS TTL, e.g. 24h -> "1d", 90m -> "90m".
+// Duration is rounded up to whole minutes, then expressed in the biggest unit dividing it exactly;
+// if none fits into the count limit, the smallest unit covering it is used, so TTL never undershoots.
+func FormatTTL(d time.Duration) (ttl string, effective time.Duration, err error) {
+	if d <= 0 {
+		return "", 0, fmt.Errorf("%w: %v", ErrInvalidTTL, d)
+	}
+	if rem := d % time.Minute; rem != 0 {
+		d += time.Minute - rem
+	}
+
+	for _, u := range ttlUnits {
+		if n := d / u.duration; d%u.duration == 0 && n <= maxTTLCount {
+			return strconv.FormatInt(int64(n), 10) + u.suffix, d, nil
+		}
+	}
+
+	for i := len(ttlUnits) - 1; i >= 0; i-- {
+		u := ttlUnits[i]
+		if n := (d + u.duration - 1) / u.duration; n <= maxTTLCount {
+			return strconv.FormatInt(int64(n), 10) + u.suffix, n * u.duration, nil
+		}
+	}
+
+	return "", 0, fmt.Errorf("%w: %v is too long", ErrInvalidTTL, d)
+}
+
+// TemporaryLink ready-to-share link of a file uploaded with TTL.
+type TemporaryLink struct {
+	FileID string
+	URL    string
+
+	// ExpiresAt is the earliest time file might disappear. Servers reclaim expired files lazily,
+	// so it might still be readable for a while after.
+	ExpiresAt time.Time
+}
+
+// UploadTemporary uploads content which expires after ttl, returning a public link to share it.
+// TTL is rounded up to what SeaweedFS supports, see FormatTTL.
+func (c *Seaweed) UploadTemporary(reader io.Reader, fileName string, size int64, collection string, ttl time.Duration) (link *TemporaryLink, err error) {
+	ttlStr, effective, err := FormatTTL(ttl)
+	if err != nil {
+		return
+	}
+
+	start := time.Now()
+	fp, err := c.Upload(reader, fileName, size, collection, ttlStr)
+	if err != nil {
+		return
+	}
+
+	var opts []URLOption
+	if name := path.Base(fileName); name != "" && name != "." && name != "/" {
+		opts = append(opts, WithFileName(name))
+	}
+
+	u, err := c.PublicURL(fp.FileID, opts...)
+	if err != nil {
+		return
+	}
+
+	link = &TemporaryLink{
+		FileID:    fp.FileID,
+		URL:       u,
+		ExpiresAt: start.Add(effective),
+	}
+	return
+}
@@ -0,0 +1,80 @@
+package goseaweedfs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatTTL(t *testing.T) {
+	for _, c := range []struct {
+		d         time.Duration
+		ttl       string
+		effective time.Duration
+	}{
+		{time.Minute, "1m", time.Minute},
+		{90 * time.Second, "2m", 2 * time.Minute},
+		{90 * time.Minute, "90m", 90 * time.Minute},
+		{24 * time.Hour, "1d", 24 * time.Hour},
+		{14 * 24 * time.Hour, "2w", 14 * 24 * time.Hour},
+		{365 * 24 * time.Hour, "1y", 365 * 24 * time.Hour},
+		{300*time.Minute + time.Minute, "6h", 6 * time.Hour},
+		{256*time.Minute + 30*time.Second, "5h", 5 * time.Hour},
+	} {
+		ttl, effective, err := FormatTTL(c.d)
+		require.Nil(t, err, c.d)
+		require.Equal(t, c.ttl, ttl, c.d)
+		require.Equal(t, c.effective, effective, c.d)
+	}
+
+	_, _, err := FormatTTL(0)
+	require.True(t, errors.Is(err, ErrInvalidTTL))
+	_, _, err = FormatTTL(256 * 365 * 24 * time.Hour)
+	require.True(t, errors.Is(err, ErrInvalidTTL))
+}
+
+func TestTemporaryLink(t *testing.T) {
+	var mu sync.Mutex
+	var assignTTL, uploadTTL string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		addr := server.Listener.Addr().String()
+		switch {
+		case r.URL.Path == "/dir/assign":
+			assignTTL = r.URL.Query().Get("ttl")
+			fmt.Fprintf(w, `{"fid":"3,01","url":%q,"publicUrl":%q,"count":1}`, addr, addr)
+		case r.URL.Path == "/dir/lookup":
+			fmt.Fprintf(w, `{"locations":[{"url":%q,"publicUrl":"cdn.example.com"}]}`, addr)
+		case r.Method == http.MethodPost:
+			uploadTTL = r.URL.Query().Get("ttl")
+			fmt.Fprint(w, `{"name":"share.txt","size":8}`)
+		}
+	}))
+	defer server.Close()
+
+	sw, err := NewSeaweed(server.URL, nil, 1024, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	start := time.Now()
+	link, err := sw.UploadTemporary(strings.NewReader("share me"), "dir/share.txt", 8, "", 90*time.Second)
+	require.Nil(t, err)
+	require.Equal(t, "3,01", link.FileID)
+	require.Equal(t, "http://cdn.example.com/3/01/share.txt", link.URL)
+	require.False(t, link.ExpiresAt.Before(start.Add(2*time.Minute)))
+	require.Equal(t, "2m", assignTTL)
+	require.Equal(t, "2m", uploadTTL)
+
+	_, err = sw.UploadTemporary(strings.NewReader("share me"), "share.txt", 8, "", 0)
+	require.True(t, errors.Is(err, ErrInvalidTTL))
+}
@@ -0,0 +1,62 @@
+package goseaweedfs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+)
+
+// ErrUnsupportedByTransport returned when an operation is not available over configured transport,
+// e.g. subscribing to metadata changes, which filer does not expose over HTTP.
+var ErrUnsupportedByTransport = fmt.Errorf("Unsupported by transport")
+
+// Transport carries master and filer metadata operations (assign, lookup, listing and metadata subscription),
+// replacing HTTP+JSON when configured with WithTransport, e.g. by the gRPC client of grpctransport module.
+// File content is always transferred over HTTP. It must be safe for concurrent use.
+type Transport interface {
+	// Lookup volume locations, see Lookuper.
+	Lookuper
+
+	// Assign file ids, args are the same as of master's /dir/assign (see ParamAssignCount etc).
+	// Failure reported by master is returned in AssignResult.Error.
+	Assign(ctx context.Context, args url.Values) (*AssignResult, error)
+
+	// ListDir lists a page of entries of dir after lastFileName, at most limit if positive. Filer is host:port
+	// of filer's HTTP endpoint. Returns ErrFileNotFound if dir does not exist.
+	ListDir(ctx context.Context, filer, dir, lastFileName string, limit int) (*FilerListing, error)
+
+	// SubscribeMetadata calls fn with every change of entries under pathPrefix on filer since given time, in order,
+	// until ctx is done or fn returns an error, which is returned.
+	SubscribeMetadata(ctx context.Context, filer, pathPrefix string, since time.Time, fn MetadataEventFunc) error
+}
+
+// MetadataEvent a change of filer entry, see Filer.SubscribeMetadata.
+type MetadataEvent struct {
+	// Directory of OldEntry, or of NewEntry if entry is created.
+	Directory string
+
+	// OldEntry is nil if entry is created, NewEntry is nil if entry is deleted.
+	OldEntry *FileInfo
+	NewEntry *FileInfo
+
+	// NewParentPath directory of NewEntry, which differs from Directory if entry is moved.
+	NewParentPath string
+
+	Time time.Time
+}
+
+// MetadataEventFunc is called with every metadata event. Returning error stops subscription.
+type MetadataEventFunc func(*MetadataEvent) error
+
+// SubscribeMetadata calls fn with every change of entries under pathPrefix since given time, until ctx is done
+// or fn returns an error. Filer publishes metadata changes over gRPC only, so a transport is required,
+// otherwise ErrUnsupportedByTransport is returned.
+func (f *Filer) SubscribeMetadata(ctx context.Context, pathPrefix string, since time.Time, fn MetadataEventFunc) error {
+	t := f.client.opts.transport
+	if t == nil {
+		return fmt.Errorf("%w: metadata subscription needs a gRPC transport, see WithTransport", ErrUnsupportedByTransport)
+	}
+	return t.SubscribeMetadata(ctx, f.base.Host, path.Clean("/"+pathPrefix), since, fn)
+}
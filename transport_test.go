@@ -0,0 +1,116 @@
+package goseaweedfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTransport serves metadata operations from memory, recording them.
+type fakeTransport struct {
+	mu     sync.Mutex
+	calls  []string
+	volume string
+}
+
+func (t *fakeTransport) record(format string, args ...interface{}) {
+	t.mu.Lock()
+	t.calls = append(t.calls, fmt.Sprintf(format, args...))
+	t.mu.Unlock()
+}
+
+func (t *fakeTransport) Lookup(_ context.Context, volumeID string, _ url.Values) (VolumeLocations, error) {
+	t.record("lookup %s", volumeID)
+	return VolumeLocations{{URL: t.volume, PublicURL: t.volume}}, nil
+}
+
+func (t *fakeTransport) Assign(_ context.Context, args url.Values) (*AssignResult, error) {
+	t.record("assign %s", args.Get(ParamCollection))
+	if args.Get(ParamCollection) == "full" {
+		return &AssignResult{Error: "no free volumes left"}, nil
+	}
+	return &AssignResult{FileID: "3,01637037d6", URL: t.volume, PublicURL: t.volume, Count: 1}, nil
+}
+
+func (t *fakeTransport) ListDir(_ context.Context, filer, dir, lastFileName string, limit int) (*FilerListing, error) {
+	t.record("list %s %s", filer, dir)
+	if dir == "/missing" {
+		return nil, ErrFileNotFound
+	}
+	return &FilerListing{Path: dir, Entries: []*FileInfo{{FullPath: dir + "/a.txt", FileSize: 3}}}, nil
+}
+
+func (t *fakeTransport) SubscribeMetadata(ctx context.Context, filer, pathPrefix string, since time.Time, fn MetadataEventFunc) error {
+	t.record("subscribe %s %s", filer, pathPrefix)
+	return fn(&MetadataEvent{Directory: pathPrefix, NewEntry: &FileInfo{FullPath: pathPrefix + "/new.txt"}, Time: since})
+}
+
+func TestTransport(t *testing.T) {
+	var httpCalls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpCalls = append(httpCalls, r.Method+" "+r.URL.Path)
+		fmt.Fprint(w, `{"name":"a.txt","size":3}`)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	transport := &fakeTransport{volume: u.Host}
+	sw, err := NewSeaweed(server.URL, []string{server.URL}, 1024, server.Client(), WithTransport(transport))
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	// content goes over HTTP to the assigned volume server, metadata over transport
+	fp, err := sw.Upload(strings.NewReader("abc"), "a.txt", 3, "logs", "")
+	require.Nil(t, err)
+	require.Equal(t, "3,01637037d6", fp.FileID)
+
+	_, err = sw.Upload(strings.NewReader("abc"), "a.txt", 3, "full", "")
+	require.True(t, errors.Is(err, ErrClusterFull), "%v", err)
+
+	result, err := sw.Lookup("3", nil)
+	require.Nil(t, err)
+	require.Equal(t, u.Host, result.VolumeLocations.Head().URL)
+
+	filer := sw.Filers()[0]
+	entries, err := filer.ListDir("logs/")
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	_, err = filer.ListDir("/missing")
+	require.True(t, errors.Is(err, ErrFileNotFound))
+
+	var events []*MetadataEvent
+	require.Nil(t, filer.SubscribeMetadata(context.Background(), "logs", time.Time{}, func(e *MetadataEvent) error {
+		events = append(events, e)
+		return nil
+	}))
+	require.Len(t, events, 1)
+	require.Equal(t, "/logs/new.txt", events[0].NewEntry.FullPath)
+
+	require.Equal(t, []string{
+		"assign logs",
+		"assign full",
+		"lookup 3",
+		"list " + u.Host + " /logs",
+		"list " + u.Host + " /missing",
+		"subscribe " + u.Host + " /logs",
+	}, transport.calls)
+	require.Equal(t, []string{"POST /3,01637037d6"}, httpCalls)
+}
+
+func TestSubscribeMetadataOverHTTP(t *testing.T) {
+	filer, err := NewFiler("http://localhost:8888", http.DefaultClient)
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	err = filer.SubscribeMetadata(context.Background(), "/", time.Time{}, func(*MetadataEvent) error { return nil })
+	require.True(t, errors.Is(err, ErrUnsupportedByTransport))
+}
@@ -0,0 +1,87 @@
+package goseaweedfs
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrUploadTooLarge return when upload exceeds configured max upload size.
+	ErrUploadTooLarge = fmt.Errorf("Upload too large")
+
+	// ErrMimeTypeNotAllowed return when upload's mime type is not one of configured allowed types.
+	ErrMimeTypeNotAllowed = fmt.Errorf("Mime type not allowed")
+
+	// ErrSizeMismatch return when uploaded stream length differs from declared file size.
+	ErrSizeMismatch = fmt.Errorf("Upload size mismatch")
+)
+
+// checkUploadSize validates declared size of upload against max upload size.
+func (c *httpClient) checkUploadSize(filename string, size int64) error {
+	if limit := c.opts.maxUploadSize; limit > 0 && size > limit {
+		return fmt.Errorf("%w: %s is %d bytes, limit is %d", ErrUploadTooLarge, filename, size, limit)
+	}
+	return nil
+}
+
+// checkMimeType validates mime type of upload against allowed mime types.
+func (c *httpClient) checkMimeType(filename, mtype string) error {
+	if len(c.opts.allowedMimeTypes) == 0 || mimeAllowed(mtype, c.opts.allowedMimeTypes) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is %q", ErrMimeTypeNotAllowed, filename, mtype)
+}
+
+// resolveMimeType returns mime type of upload: given one, or by file extension, or sniffed from content.
+// Returned content must be read instead of r, which may have been partially consumed by sniffing.
+func resolveMimeType(filename, mtype string, r io.Reader) (string, io.Reader, error) {
+	if mtype == "" {
+		mtype = mime.TypeByExtension(strings.ToLower(filepath.Ext(filename)))
+	}
+	if mtype != "" {
+		return mtype, r, nil
+	}
+	return sniffContentType(r)
+}
+
+// mimeAllowed matches mime type, without parameters, against patterns like "image/png" or "image/*".
+func mimeAllowed(mtype string, patterns []string) bool {
+	if mediaType, _, err := mime.ParseMediaType(mtype); err == nil {
+		mtype = mediaType
+	}
+
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		if p == mtype || (strings.HasSuffix(p, "/*") && strings.HasPrefix(mtype, p[:len(p)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadGuard enforces max upload size and declared size while upload is streamed.
+type uploadGuard struct {
+	r        io.Reader
+	filename string
+	expected int64 // non-positive if unknown
+	limit    int64 // non-positive if unlimited
+	n        int64
+}
+
+func (g *uploadGuard) Read(p []byte) (n int, err error) {
+	n, err = g.r.Read(p)
+	g.n += int64(n)
+
+	switch {
+	case g.limit > 0 && g.n > g.limit:
+		err = fmt.Errorf("%w: %s exceeds limit of %d bytes", ErrUploadTooLarge, g.filename, g.limit)
+	case g.expected > 0 && g.n > g.expected:
+		err = fmt.Errorf("%w: %s is longer than declared %d bytes", ErrSizeMismatch, g.filename, g.expected)
+	case err == io.EOF && g.expected > 0 && g.n < g.expected:
+		err = fmt.Errorf("%w: %s has %d bytes, declared %d", ErrSizeMismatch, g.filename, g.n, g.expected)
+	}
+	return
+}
@@ -0,0 +1,121 @@
+package goseaweedfs
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMimeAllowed(t *testing.T) {
+	patterns := []string{"image/*", "text/plain"}
+	require.True(t, mimeAllowed("image/png", patterns))
+	require.True(t, mimeAllowed("text/plain; charset=utf-8", patterns))
+	require.False(t, mimeAllowed("text/html", patterns))
+	require.False(t, mimeAllowed("", patterns))
+}
+
+func TestUploadGuard(t *testing.T) {
+	read := func(content string, expected, limit int64) error {
+		_, err := ioutil.ReadAll(&uploadGuard{r: strings.NewReader(content), expected: expected, limit: limit})
+		return err
+	}
+
+	require.Nil(t, read("content", 7, 0))
+	require.Nil(t, read("content", 0, 7))
+	require.True(t, errors.Is(read("content", 8, 0), ErrSizeMismatch))
+	require.True(t, errors.Is(read("content", 6, 0), ErrSizeMismatch))
+	require.True(t, errors.Is(read("content", 0, 6), ErrUploadTooLarge))
+}
+
+func TestUploadValidation(t *testing.T) {
+	var uploads int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, err := r.FormFile("file"); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		atomic.AddInt32(&uploads, 1)
+		fmt.Fprint(w, `{"name":"a","size":7}`)
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client(), WithMaxUploadSize(10), WithAllowedMimeTypes("text/*"))
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	_, err = filer.Upload(strings.NewReader("content"), 7, "/a.txt", "", "")
+	require.Nil(t, err)
+
+	// sniffed as text
+	_, err = filer.Upload(strings.NewReader("content"), 7, "/a", "", "")
+	require.Nil(t, err)
+
+	// rejected before sending
+	_, err = filer.Upload(strings.NewReader("content"), 7, "/a.png", "", "")
+	require.True(t, errors.Is(err, ErrMimeTypeNotAllowed))
+	_, err = filer.Upload(strings.NewReader("too long content"), 16, "/a.txt", "", "")
+	require.True(t, errors.Is(err, ErrUploadTooLarge))
+
+	// rejected while streaming
+	_, err = filer.Upload(strings.NewReader("too long content"), 0, "/a.txt", "", "")
+	require.True(t, errors.Is(err, ErrUploadTooLarge))
+	_, err = filer.Upload(strings.NewReader("content"), 8, "/a.txt", "", "")
+	require.True(t, errors.Is(err, ErrSizeMismatch))
+
+	require.EqualValues(t, 2, atomic.LoadInt32(&uploads))
+}
+
+func TestChunkedUploadValidation(t *testing.T) {
+	var uploads int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr := server.Listener.Addr().String()
+		switch {
+		case r.URL.Path == "/dir/assign":
+			fmt.Fprintf(w, `{"fid":"3,01","url":%q,"publicUrl":%q,"count":1}`, addr, addr)
+		case r.Method == http.MethodPost:
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data, _ := ioutil.ReadAll(f)
+			atomic.AddInt32(&uploads, 1)
+			fmt.Fprintf(w, `{"name":"a","size":%d}`, len(data))
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+
+	sw, err := NewSeaweed(server.URL, nil, 4, server.Client(), WithMaxUploadSize(12), WithAllowedMimeTypes("text/*"))
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	upload := func(content string, size int64, name string) (*ChunkManifest, error) {
+		return sw.UploadFilePart(NewFilePartFromReader(ioutil.NopCloser(strings.NewReader(content)), name, size), nil)
+	}
+
+	// sniffed as text, like a single part upload
+	cm, err := upload("content", 7, "a")
+	require.Nil(t, err)
+	require.Equal(t, "text/plain; charset=utf-8", cm.Mime)
+
+	atomic.StoreInt32(&uploads, 0)
+	_, err = upload("\x89PNG\r\n\x1a\n", 8, "a")
+	require.True(t, errors.Is(err, ErrMimeTypeNotAllowed))
+	require.EqualValues(t, 0, atomic.LoadInt32(&uploads))
+
+	// stream longer than declared is not silently truncated
+	_, err = upload("content, and some more", 7, "a.txt")
+	require.True(t, errors.Is(err, ErrSizeMismatch))
+	_, err = upload("content", 8, "a.txt")
+	require.True(t, errors.Is(err, ErrSizeMismatch))
+}
@@ -0,0 +1,150 @@
+package goseaweedfs
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+)
+
+// VolumeInfo status of a volume replica, responsed from master's /vol/status.
+type VolumeInfo struct {
+	ID               uint32 `json:"Id"`
+	Collection       string `json:"Collection"`
+	Size             uint64 `json:"Size"`
+	FileCount        uint64 `json:"FileCount"`
+	DeleteCount      uint64 `json:"DeleteCount"`
+	DeletedByteCount uint64 `json:"DeletedByteCount"`
+	ReadOnly         bool   `json:"ReadOnly"`
+	CompactRevision  uint32 `json:"CompactRevision"`
+
+	// DataCenter, Rack and Server locate the replica, filled by client.
+	DataCenter string `json:"-"`
+	Rack       string `json:"-"`
+	Server     string `json:"-"`
+}
+
+// volumeStatus raw result of master's /vol/status: data center -> rack -> server -> volumes.
+type volumeStatus struct {
+	Volumes struct {
+		DataCenters map[string]map[string]map[string][]*VolumeInfo
+		Free        int
+		Max         int
+	}
+	Error string
+}
+
+// CollectionUsage disk usage of a collection. Replicas of a volume are counted once.
+type CollectionUsage struct {
+	Collection       string
+	Volumes          int
+	Size             uint64
+	FileCount        uint64
+	DeleteCount      uint64
+	DeletedByteCount uint64
+}
+
+// DirUsage disk usage of a filer directory.
+type DirUsage struct {
+	Path  string
+	Files int64
+	Dirs  int64
+	Size  int64
+
+	// Quota configured on directory (e.g. a bucket) in bytes, 0 means unlimited.
+	Quota int64
+}
+
+// Exceeds reports whether writing n more bytes into directory would exceed its quota.
+func (u *DirUsage) Exceeds(n int64) bool {
+	return u.Quota > 0 && u.Size+n > u.Quota
+}
+
+// VolumeStatus returns status of every volume replica in cluster, ordered by volume id.
+func (c *Seaweed) VolumeStatus(ctx context.Context) (volumes []*VolumeInfo, err error) {
+	status := &volumeStatus{}
+	if _, err = c.client.getJSONContext(ctx, encodeURI(c.masterURL(), "/vol/status", nil), nil, status); err != nil {
+		return
+	}
+	if status.Error != "" {
+		return nil, fmt.Errorf("/vol/status: %s", status.Error)
+	}
+
+	for dc, racks := range status.Volumes.DataCenters {
+		for rack, servers := range racks {
+			for server, vols := range servers {
+				for _, v := range vols {
+					v.DataCenter, v.Rack, v.Server = dc, rack, server
+					volumes = append(volumes, v)
+				}
+			}
+		}
+	}
+
+	sort.Slice(volumes, func(i, j int) bool {
+		if volumes[i].ID != volumes[j].ID {
+			return volumes[i].ID < volumes[j].ID
+		}
+		return volumes[i].Server < volumes[j].Server
+	})
+	return
+}
+
+// CollectionUsage returns disk usage per collection. Default collection is keyed by empty string.
+func (c *Seaweed) CollectionUsage(ctx context.Context) (usage map[string]*CollectionUsage, err error) {
+	volumes, err := c.VolumeStatus(ctx)
+	if err != nil {
+		return
+	}
+
+	// replicas of a volume might be slightly out of sync, take the biggest one
+	largest := make(map[uint32]*VolumeInfo, len(volumes))
+	for _, v := range volumes {
+		if cur, ok := largest[v.ID]; !ok || v.Size > cur.Size {
+			largest[v.ID] = v
+		}
+	}
+
+	usage = make(map[string]*CollectionUsage)
+	for _, v := range largest {
+		u, ok := usage[v.Collection]
+		if !ok {
+			u = &CollectionUsage{Collection: v.Collection}
+			usage[v.Collection] = u
+		}
+		u.Volumes++
+		u.Size += v.Size
+		u.FileCount += v.FileCount
+		u.DeleteCount += v.DeleteCount
+		u.DeletedByteCount += v.DeletedByteCount
+	}
+	return
+}
+
+// Usage sums up files and sizes under dir recursively, along with quota configured on dir.
+// Usage walks the whole tree, so it might be expensive for big directories.
+func (f *Filer) Usage(dir string) (usage *DirUsage, err error) {
+	fi, err := f.Stat(dir)
+	if err != nil {
+		return
+	}
+
+	root := path.Clean("/" + dir)
+	usage = &DirUsage{Path: dir, Quota: fi.Quota}
+	err = f.Walk(context.Background(), root, func(p string, entry *FileInfo, err error) error {
+		if err != nil || p == root {
+			return err
+		}
+		if entry.IsDir() {
+			usage.Dirs++
+		} else {
+			usage.Files++
+			usage.Size += entry.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		usage = nil
+	}
+	return
+}
@@ -0,0 +1,111 @@
+package goseaweedfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testVolumeStatus = `{"Version":"30GB 2.12","Volumes":{"DataCenters":{"dc1":{"rack1":{
+	"10.0.0.1:8080":[{"Id":1,"Size":1000,"Collection":"","FileCount":10,"DeleteCount":2,"DeletedByteCount":200},
+		{"Id":2,"Size":5000,"Collection":"pics","FileCount":50,"DeleteCount":0,"DeletedByteCount":0,"ReadOnly":true}],
+	"10.0.0.2:8080":[{"Id":1,"Size":900,"Collection":"","FileCount":9,"DeleteCount":2,"DeletedByteCount":200},
+		{"Id":3,"Size":3000,"Collection":"pics","FileCount":30,"DeleteCount":3,"DeletedByteCount":1500}]
+	}}},"Free":5,"Max":10}}`
+
+func newTestMaster(t *testing.T, routes map[string]string) (*Seaweed, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		fmt.Fprint(w, body)
+	}))
+
+	sw, err := NewSeaweed(server.URL, nil, 1024, server.Client())
+	require.Nil(t, err)
+	return sw, func() {
+		_ = sw.Close()
+		server.Close()
+	}
+}
+
+func TestCollectionUsage(t *testing.T) {
+	sw, done := newTestMaster(t, map[string]string{"/vol/status": testVolumeStatus})
+	defer done()
+
+	volumes, err := sw.VolumeStatus(context.Background())
+	require.Nil(t, err)
+	require.Len(t, volumes, 4)
+	require.EqualValues(t, 1, volumes[0].ID)
+	require.Equal(t, "10.0.0.1:8080", volumes[0].Server)
+	require.Equal(t, "dc1", volumes[0].DataCenter)
+	require.Equal(t, "rack1", volumes[0].Rack)
+	require.True(t, volumes[2].ReadOnly)
+
+	usage, err := sw.CollectionUsage(context.Background())
+	require.Nil(t, err)
+	require.Len(t, usage, 2)
+	require.Equal(t, &CollectionUsage{Volumes: 1, Size: 1000, FileCount: 10, DeleteCount: 2, DeletedByteCount: 200}, usage[""])
+	require.Equal(t, &CollectionUsage{Collection: "pics", Volumes: 2, Size: 8000, FileCount: 80, DeleteCount: 3, DeletedByteCount: 1500}, usage["pics"])
+}
+
+func TestDirUsageExceeds(t *testing.T) {
+	u := &DirUsage{Size: 90}
+	require.False(t, u.Exceeds(1<<40))
+
+	u.Quota = 100
+	require.False(t, u.Exceeds(10))
+	require.True(t, u.Exceeds(11))
+}
+
+func TestFilerUsage(t *testing.T) {
+	entries := map[string]*FileInfo{
+		"/usage":       {FullPath: "/usage", FileMode: os.ModeDir | 0755, Quota: 100},
+		"/usage/a.txt": {FullPath: "/usage/a.txt", FileSize: 30},
+		"/usage/sub":   {FullPath: "/usage/sub", FileMode: os.ModeDir | 0755},
+		"/usage/sub/b": {FullPath: "/usage/sub/b", FileSize: 50},
+	}
+	children := map[string][]string{"/usage": {"/usage/a.txt", "/usage/sub"}, "/usage/sub": {"/usage/sub/b"}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := path.Clean(r.URL.Path)
+		entry, ok := entries[p]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("metadata") == "true" {
+			_ = json.NewEncoder(w).Encode(entry)
+			return
+		}
+
+		listing := &FilerListing{Path: p}
+		for _, c := range children[p] {
+			listing.Entries = append(listing.Entries, entries[c])
+		}
+		_ = json.NewEncoder(w).Encode(listing)
+	}))
+	defer server.Close()
+
+	filer, err := NewFiler(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = filer.Close() }()
+
+	du, err := filer.Usage("/usage")
+	require.Nil(t, err)
+	require.Equal(t, &DirUsage{Path: "/usage", Files: 2, Dirs: 1, Size: 80, Quota: 100}, du)
+	require.False(t, du.Exceeds(20))
+	require.True(t, du.Exceeds(21))
+
+	_, err = filer.Usage("/missing")
+	require.True(t, errors.Is(err, ErrFileNotFound))
+}
@@ -1,13 +1,22 @@
 package goseaweedfs
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
 
 	workerpool "github.com/linxGnu/gumble/worker-pool"
 )
@@ -39,26 +48,35 @@ func encodeURI(base url.URL, path string, args url.Values) string {
 	return base.String()
 }
 
-func valid(c rune) bool {
-	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || '.' == c || '-' == c || '_' == c
+// parseVolumeID returns volume id part of file id, which is either "<volume id>,<key><cookie>" or "<volume id>/<key><cookie>".
+func parseVolumeID(fileID string) (string, error) {
+	sep := "/"
+	if strings.Contains(fileID, ",") {
+		sep = ","
+	}
+
+	parts := strings.Split(fileID, sep)
+	if len(parts) != 2 { // wrong file id format
+		return "", errors.New("Invalid fileID " + fileID)
+	}
+	return parts[0], nil
 }
 
+// normalizeName drops characters which have no place in a file name: control characters, which would otherwise
+// break into multipart headers, path separators and invalid UTF-8.
 func normalizeName(st string) string {
-	for _, _c := range st {
-		if !valid(_c) {
-			var sb strings.Builder
-			sb.Grow(len(st))
-
-			for _, c := range st {
-				if valid(c) {
-					_, _ = sb.WriteRune(c)
-				}
-			}
-
-			return sb.String()
+	return strings.Map(func(c rune) rune {
+		if c == utf8.RuneError || c == '/' || c == '\\' || unicode.IsControl(c) {
+			return -1
 		}
-	}
-	return st
+		return c
+	}, st)
+}
+
+// formDataDisposition builds Content-Disposition of a multipart file part. Quotes are escaped within quoted-string,
+// non-ASCII names are percent-encoded as filename* (RFC 2231).
+func formDataDisposition(field, filename string) string {
+	return mime.FormatMediaType("form-data", map[string]string{"name": field, "filename": normalizeName(filename)})
 }
 
 func drainAndClose(body io.ReadCloser) {
@@ -82,9 +100,183 @@ func normalize(values url.Values, collection, ttl string) url.Values {
 	return values
 }
 
-func readAll(r *http.Response) (body []byte, statusCode int, err error) {
+// contextReader stops reading once ctx is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// sizeGuard fails reading with ErrResponseTooLarge when underlying reader has more than limit bytes.
+type sizeGuard struct {
+	r         io.Reader
+	remaining int64
+}
+
+func newSizeGuard(r io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+	return &sizeGuard{r: r, remaining: limit}
+}
+
+func (g *sizeGuard) Read(p []byte) (n int, err error) {
+	if g.remaining <= 0 {
+		// probe one more byte to distinguish EOF from oversized body
+		var b [1]byte
+		if n, err = g.r.Read(b[:]); n > 0 {
+			return 0, ErrResponseTooLarge
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > g.remaining {
+		p = p[:g.remaining]
+	}
+	n, err = g.r.Read(p)
+	g.remaining -= int64(n)
+	return
+}
+
+func readAll(r *http.Response, limit int64) (body []byte, statusCode int, err error) {
 	statusCode = r.StatusCode
-	body, err = ioutil.ReadAll(r.Body)
-	r.Body.Close()
+	body, err = ioutil.ReadAll(newSizeGuard(r.Body, limit))
+	_ = r.Body.Close()
 	return
 }
+
+func decodeJSON(r *http.Response, limit int64, out interface{}) (statusCode int, err error) {
+	statusCode = r.StatusCode
+	if err = json.NewDecoder(newSizeGuard(r.Body, limit)).Decode(out); err == nil {
+		drainAndClose(r.Body)
+	} else {
+		_ = r.Body.Close()
+	}
+	return
+}
+
+// metadataHeader converts custom metadata into Seaweed- prefixed headers.
+func metadataHeader(md map[string]string) http.Header {
+	header := make(http.Header, len(md))
+	for k, v := range md {
+		header.Set("Seaweed-"+k, v)
+	}
+	return header
+}
+
+// taskGroup runs functions with bounded concurrency, keeping the first error.
+type taskGroup struct {
+	sem  chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+	err  atomic.Value
+}
+
+func newTaskGroup(concurrency int) *taskGroup {
+	return &taskGroup{
+		sem: make(chan struct{}, concurrency),
+	}
+}
+
+// Go runs fn once a slot is available. fn is skipped if a previous one failed.
+func (g *taskGroup) Go(fn func() error) {
+	g.sem <- struct{}{}
+	if g.Err() != nil {
+		<-g.sem
+		return
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer func() {
+			<-g.sem
+			g.wg.Done()
+		}()
+
+		if err := fn(); err != nil {
+			g.once.Do(func() { g.err.Store(err) })
+		}
+	}()
+}
+
+// Err returns first error so far.
+func (g *taskGroup) Err() error {
+	err, _ := g.err.Load().(error)
+	return err
+}
+
+// Wait for all running functions, returning first error.
+func (g *taskGroup) Wait() error {
+	g.wg.Wait()
+	return g.Err()
+}
+
+// sniffLen is the number of bytes http.DetectContentType considers.
+const sniffLen = 512
+
+// sniffContentType detects content type from the first bytes of r. Returned reader yields the whole content again.
+// Empty content has no type.
+func sniffContentType(r io.Reader) (mtype string, content io.Reader, err error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+
+	head = head[:n]
+	if n > 0 {
+		mtype = http.DetectContentType(head)
+	}
+	return mtype, io.MultiReader(bytes.NewReader(head), r), err
+}
+
+// retryReadOnly runs write, re-assigning and retrying if target volume turned read only meanwhile.
+// Retrying requires reader to be rewindable (io.Seeker), otherwise the error is returned as is.
+func retryReadOnly(r io.Reader, reassign func() error, write func() error) (err error) {
+	seeker, _ := r.(io.Seeker)
+	if reassign == nil {
+		seeker = nil
+	}
+
+	var offset int64
+	if seeker != nil {
+		if offset, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seeker, err = nil, nil
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err = write(); err == nil || seeker == nil || attempt >= maxReassign || !errors.Is(err, ErrVolumeReadOnly) {
+			return
+		}
+
+		if _, err = seeker.Seek(offset, io.SeekStart); err == nil {
+			err = reassign()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writeError classifies error responded by master/volume server on write path.
+func writeError(err error) error {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "no free volume"),
+		strings.Contains(msg, "no more writable volume"),
+		strings.Contains(msg, "no writable volume"),
+		strings.Contains(msg, "not enough free volume"):
+		return fmt.Errorf("%w: %v", ErrClusterFull, err)
+	case strings.Contains(msg, "read only"),
+		strings.Contains(msg, "readonly"):
+		return fmt.Errorf("%w: %v", ErrVolumeReadOnly, err)
+	}
+	return err
+}
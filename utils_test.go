@@ -0,0 +1,189 @@
+package goseaweedfs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+	}
+}
+
+func TestReadAllWithLimit(t *testing.T) {
+	data, code, err := readAll(newTestResponse("abcd"), 4)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "abcd", string(data))
+
+	_, _, err = readAll(newTestResponse("abcde"), 4)
+	require.Equal(t, ErrResponseTooLarge, err)
+
+	data, _, err = readAll(newTestResponse("abcde"), 0)
+	require.Nil(t, err)
+	require.Equal(t, "abcde", string(data))
+}
+
+func TestDecodeJSONWithLimit(t *testing.T) {
+	var result AssignResult
+	_, err := decodeJSON(newTestResponse(`{"fid":"1,0a1653fd0f","count":1}`), 1024, &result)
+	require.Nil(t, err)
+	require.Equal(t, "1,0a1653fd0f", result.FileID)
+	require.EqualValues(t, 1, result.Count)
+
+	_, err = decodeJSON(newTestResponse(`{"fid":"1,0a1653fd0f","count":1}`), 8, &result)
+	require.Equal(t, ErrResponseTooLarge, err)
+}
+
+func TestTaskGroup(t *testing.T) {
+	g := newTaskGroup(2)
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	for i := 0; i < 10; i++ {
+		g.Go(func() error {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		})
+	}
+	require.Nil(t, g.Wait())
+	require.True(t, maxRunning <= 2)
+
+	g = newTaskGroup(1)
+	g.Go(func() error { return fmt.Errorf("Fake error") })
+	require.EqualError(t, g.Wait(), "Fake error")
+
+	called := false
+	g.Go(func() error { called = true; return nil })
+	require.False(t, called)
+}
+
+func TestWriteError(t *testing.T) {
+	require.True(t, errors.Is(writeError(errors.New("No free volumes left!")), ErrClusterFull))
+	require.True(t, errors.Is(writeError(errors.New("failed to find writable volumes: no more writable volumes")), ErrClusterFull))
+	require.True(t, errors.Is(writeError(errors.New("volume 3 is read only")), ErrVolumeReadOnly))
+
+	err := errors.New("connection refused")
+	require.Equal(t, err, writeError(err))
+}
+
+func TestRetryReadOnly(t *testing.T) {
+	r := bytes.NewReader([]byte("content"))
+
+	var reassigned, writes int
+	reassign := func() error { reassigned++; return nil }
+	write := func() error {
+		writes++
+		data, _ := ioutil.ReadAll(r)
+		require.Equal(t, "content", string(data))
+		if writes < 2 {
+			return writeError(errors.New("volume 3 is read only"))
+		}
+		return nil
+	}
+	require.Nil(t, retryReadOnly(r, reassign, write))
+	require.Equal(t, 1, reassigned)
+	require.Equal(t, 2, writes)
+
+	// always read only: gives up after max attempts
+	_, _ = r.Seek(0, io.SeekStart)
+	reassigned = 0
+	err := retryReadOnly(r, reassign, func() error {
+		_, _ = ioutil.ReadAll(r)
+		return writeError(errors.New("read only"))
+	})
+	require.True(t, errors.Is(err, ErrVolumeReadOnly))
+	require.Equal(t, maxReassign, reassigned)
+
+	// not rewindable
+	reassigned = 0
+	err = retryReadOnly(ioutil.NopCloser(r), reassign, func() error { return writeError(errors.New("read only")) })
+	require.True(t, errors.Is(err, ErrVolumeReadOnly))
+	require.Equal(t, 0, reassigned)
+}
+
+func TestSniffContentType(t *testing.T) {
+	png := append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{0}, 1000)...)
+	mtype, r, err := sniffContentType(bytes.NewReader(png))
+	require.Nil(t, err)
+	require.Equal(t, "image/png", mtype)
+	data, _ := ioutil.ReadAll(r)
+	require.Equal(t, png, data)
+
+	mtype, r, err = sniffContentType(bytes.NewReader([]byte("hello")))
+	require.Nil(t, err)
+	require.Equal(t, "text/plain; charset=utf-8", mtype)
+	data, _ = ioutil.ReadAll(r)
+	require.Equal(t, "hello", string(data))
+
+	mtype, _, err = sniffContentType(bytes.NewReader(nil))
+	require.Nil(t, err)
+	require.Empty(t, mtype)
+}
+
+func TestFormDataDisposition(t *testing.T) {
+	names := map[string]string{
+		"a.txt":                            "a.txt",
+		`say "hi".txt`:                     `say "hi".txt`,
+		`back\\slash\".txt`:                `backslash".txt`,
+		"a\r\nContent-Type: text/html.txt": "aContent-Type: texthtml.txt",
+		"a\";\r\n\r\n<script>.txt":         `a";<script>.txt`,
+		"../../etc/passwd":                 "....etcpasswd",
+		"résumé 履歴書.pdf":                   "résumé 履歴書.pdf",
+		"bad\xff\xfeutf8.txt":              "badutf8.txt",
+		"tab\tand\x00nul.txt":              "tabandnul.txt",
+		"":                                 "",
+	}
+
+	for name, expected := range names {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", formDataDisposition("file", name))
+		h.Set("Content-Type", "text/plain")
+		part, err := mw.CreatePart(h)
+		require.Nil(t, err)
+		_, _ = part.Write([]byte("content"))
+		require.Nil(t, mw.Close())
+
+		mr := multipart.NewReader(&buf, mw.Boundary())
+		p, err := mr.NextPart()
+		require.Nil(t, err, "%q", name)
+		require.Equal(t, "file", p.FormName(), "%q", name)
+		require.Equal(t, expected, p.FileName(), "%q", name)
+		require.Len(t, p.Header, 2, "%q", name)
+		require.Equal(t, "text/plain", p.Header.Get("Content-Type"), "%q", name)
+
+		data, err := ioutil.ReadAll(p)
+		require.Nil(t, err)
+		require.Equal(t, "content", string(data))
+
+		_, err = mr.NextPart()
+		require.Equal(t, io.EOF, err, "%q", name)
+	}
+}
@@ -0,0 +1,117 @@
+package goseaweedfs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// MinSupportedVersion is the oldest SeaweedFS version supported by this client.
+const MinSupportedVersion = "1.44"
+
+// ErrValidation return when validating client configuration against cluster failed.
+var ErrValidation = fmt.Errorf("Validation failed")
+
+var minSupportedVersion = ParseServerVersion(MinSupportedVersion)
+
+// EndpointReport validation result of a master/filer endpoint.
+type EndpointReport struct {
+	URL        string
+	Reachable  bool
+	Authorized bool
+	StatusCode int
+
+	// Version of server, empty if server does not report it.
+	Version   string
+	Supported bool
+
+	Error string
+}
+
+func (r *EndpointReport) ok() bool {
+	return r.Error == ""
+}
+
+// ValidationReport result of validating client configuration against cluster.
+type ValidationReport struct {
+	Master EndpointReport
+	Filers []EndpointReport
+
+	// Collections reports whether each expected collection exists.
+	Collections map[string]bool
+}
+
+// Err summarizes failures of report, nil if everything is fine.
+func (r *ValidationReport) Err() error {
+	var problems []string
+
+	endpoints := append([]EndpointReport{r.Master}, r.Filers...)
+	for i := range endpoints {
+		if e := &endpoints[i]; !e.ok() {
+			problems = append(problems, fmt.Sprintf("%s: %s", e.URL, e.Error))
+		}
+	}
+	for col, exists := range r.Collections {
+		if !exists {
+			problems = append(problems, fmt.Sprintf("collection %s does not exist", col))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrValidation, strings.Join(problems, "; "))
+}
+
+// Validate checks that configured master and filers are reachable, authorized and running supported versions,
+// and that the expected collections exist. Intended to be called at startup, so services fail fast on misconfiguration.
+func (c *Seaweed) Validate(ctx context.Context, collections ...string) (report *ValidationReport, err error) {
+	master := c.masterURL()
+	report = &ValidationReport{
+		Master: EndpointReport{URL: master.String()},
+	}
+
+	status := &SystemStatus{}
+	report.Master.StatusCode, err = c.client.getJSONContext(ctx, encodeURI(master, "/dir/status", nil), nil, status)
+	checkEndpoint(&report.Master, status.Version, err)
+
+	if len(collections) > 0 {
+		report.Collections = make(map[string]bool, len(collections))
+		for _, col := range collections {
+			report.Collections[col] = false
+		}
+		for _, layout := range status.Topology.Layouts {
+			if _, ok := report.Collections[layout.Collection]; ok {
+				report.Collections[layout.Collection] = true
+			}
+		}
+	}
+
+	for _, filer := range c.Filers() {
+		r := EndpointReport{URL: filer.base.String()}
+
+		var header http.Header
+		r.StatusCode, header, err = filer.client.probe(ctx, http.MethodHead, encodeURI(*filer.base, "/", nil))
+		checkEndpoint(&r, header.Get("Server"), err)
+
+		report.Filers = append(report.Filers, r)
+	}
+
+	err = report.Err()
+	return
+}
+
+func checkEndpoint(r *EndpointReport, version string, err error) {
+	r.Reachable = r.StatusCode != 0
+	r.Authorized = r.StatusCode != http.StatusUnauthorized && r.StatusCode != http.StatusForbidden
+	v := ParseServerVersion(version)
+	if v.Known() {
+		r.Version = v.String()
+	}
+	r.Supported = v.AtLeast(minSupportedVersion.Major, minSupportedVersion.Minor)
+
+	if r.Error = endpointProblem(r.StatusCode, err); r.Error == "" && !r.Supported {
+		r.Error = fmt.Sprintf("unsupported version %s, require %s+", r.Version, MinSupportedVersion)
+	}
+}
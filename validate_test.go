@@ -0,0 +1,69 @@
+package goseaweedfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckEndpoint(t *testing.T) {
+	r := EndpointReport{URL: "http://localhost:9333", StatusCode: http.StatusOK}
+	checkEndpoint(&r, "30GB 1.44", nil)
+	require.True(t, r.ok())
+	require.Equal(t, "1.44", r.Version)
+
+	r = EndpointReport{URL: "http://localhost:9333", StatusCode: http.StatusOK}
+	checkEndpoint(&r, "SeaweedFS Filer 1.10", nil)
+	require.False(t, r.ok())
+
+	r = EndpointReport{URL: "http://localhost:9333", StatusCode: http.StatusUnauthorized}
+	checkEndpoint(&r, "", nil)
+	require.False(t, r.Authorized)
+
+	r = EndpointReport{URL: "http://localhost:9333"}
+	checkEndpoint(&r, "", fmt.Errorf("connection refused"))
+	require.False(t, r.Reachable)
+
+	report := &ValidationReport{Master: r, Collections: map[string]bool{"col": false}}
+	require.NotNil(t, report.Err())
+}
+
+func TestValidateReport(t *testing.T) {
+	master := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Version":"30GB 2.12","Topology":{"Layouts":[{"Collection":"logs"}]}}`)
+	}))
+	defer master.Close()
+	filer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "SeaweedFS Filer 30GB 2.12")
+	}))
+	defer filer.Close()
+	denied := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer denied.Close()
+
+	sw, err := NewSeaweed(master.URL, []string{filer.URL}, 1024, master.Client())
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	report, err := sw.Validate(context.Background(), "logs")
+	require.Nil(t, err)
+	require.Equal(t, "2.12", report.Master.Version)
+	require.True(t, report.Collections["logs"])
+	require.Len(t, report.Filers, 1)
+	require.True(t, report.Filers[0].ok())
+
+	sw, err = NewSeaweed(master.URL, []string{denied.URL}, 1024, master.Client())
+	require.Nil(t, err)
+	defer func() { _ = sw.Close() }()
+
+	report, err = sw.Validate(context.Background(), "logs", "missing")
+	require.True(t, errors.Is(err, ErrValidation))
+	require.False(t, report.Collections["missing"])
+	require.False(t, report.Filers[0].Authorized)
+}
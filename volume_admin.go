@@ -0,0 +1,144 @@
+package goseaweedfs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// VolumeAdmin low level client of a volume server, for tooling which manages needles and volumes directly.
+// According to https://github.com/chrislusf/seaweedfs/wiki/Volume-Server-API. VolumeAdmin is safe for concurrent use.
+type VolumeAdmin struct {
+	base   *url.URL
+	client *httpClient
+	shared bool
+}
+
+// VolumeServerStatus status of a volume server.
+type VolumeServerStatus struct {
+	Version string
+	Volumes []*VolumeInfo
+	Error   string
+}
+
+// NewVolumeAdmin new volume admin with volume server's url.
+func NewVolumeAdmin(u string, client *http.Client, opts ...Option) (v *VolumeAdmin, err error) {
+	base, err := parseURI(u)
+	if err == nil {
+		v = &VolumeAdmin{
+			base:   base,
+			client: newHTTPClient(client, newOptions(opts)),
+		}
+	}
+	return
+}
+
+// VolumeAdmin returns volume admin of a volume server (e.g. "localhost:8080"), sharing client with c.
+func (c *Seaweed) VolumeAdmin(server string) *VolumeAdmin {
+	base := c.masterURL()
+	base.Host = server
+
+	return &VolumeAdmin{
+		base:   &base,
+		client: c.client,
+		shared: true,
+	}
+}
+
+// Close underlying daemons. Volume admin obtained from Seaweed shares its daemons, closing it is no-op.
+func (v *VolumeAdmin) Close() (err error) {
+	if !v.shared {
+		err = v.client.Close()
+	}
+	return
+}
+
+// Status returns volumes hosted by volume server.
+func (v *VolumeAdmin) Status(ctx context.Context) (status *VolumeServerStatus, err error) {
+	status = &VolumeServerStatus{}
+	if _, err = v.client.getJSONContext(ctx, encodeURI(*v.base, "/status", nil), nil, status); err != nil {
+		status = nil
+	}
+	return
+}
+
+// ReadNeedle reads needle by file id. Deleted needle which is not vacuumed yet could be read with readDeleted.
+// Returned result must be closed by caller.
+func (v *VolumeAdmin) ReadNeedle(fileID string, readDeleted bool, header http.Header) (result *DownloadResult, err error) {
+	var args url.Values
+	if readDeleted {
+		args = url.Values{"readDeleted": []string{"true"}}
+	}
+	result, err = v.client.fetch(http.MethodGet, encodeURI(*v.base, fileID, args), header)
+	return
+}
+
+// WriteNeedle writes needle with file id directly to volume server. Positive ts (unix seconds) sets
+// modification time of needle, which is used by replication/restore tooling to keep original times.
+func (v *VolumeAdmin) WriteNeedle(fileID, fileName string, content io.Reader, size int64, ts int64, header http.Header) (result *UploadResult, err error) {
+	args := url.Values{}
+	if ts > 0 {
+		args.Set("ts", strconv.FormatInt(ts, 10))
+	}
+
+	// collection of a needle is not known here, it is accounted to empty one
+	quotaDone, err := v.client.reserveQuota("", size)
+	if err != nil {
+		return
+	}
+
+	result = &UploadResult{}
+	defer func() {
+		var written int64
+		if result != nil {
+			written = result.Size
+		}
+		quotaDone(written, err)
+	}()
+
+	if _, err = v.client.upload(encodeURI(*v.base, fileID, args), fileName, content, size, "", header, result); err != nil {
+		result = nil
+	}
+	return
+}
+
+// DeleteNeedle deletes needle by file id.
+func (v *VolumeAdmin) DeleteNeedle(fileID string) (err error) {
+	_, err = v.client.delete(encodeURI(*v.base, fileID, nil))
+	return
+}
+
+// AssignVolume creates a volume on volume server.
+func (v *VolumeAdmin) AssignVolume(volumeID uint32, collection, replication, ttl string) (err error) {
+	args := normalize(volumeArgs(volumeID), collection, ttl)
+	if replication != "" {
+		args.Set(ParamAssignReplication, replication)
+	}
+	return v.admin("/admin/assign_volume", args)
+}
+
+// MountVolume mounts an existing volume, making it available for reads and writes.
+func (v *VolumeAdmin) MountVolume(volumeID uint32) error {
+	return v.admin("/admin/volume/mount", volumeArgs(volumeID))
+}
+
+// UnmountVolume unmounts a volume, keeping its files on disk.
+func (v *VolumeAdmin) UnmountVolume(volumeID uint32) error {
+	return v.admin("/admin/volume/unmount", volumeArgs(volumeID))
+}
+
+// DeleteVolume deletes a volume along with its files.
+func (v *VolumeAdmin) DeleteVolume(volumeID uint32) error {
+	return v.admin("/admin/volume/delete", volumeArgs(volumeID))
+}
+
+func (v *VolumeAdmin) admin(path string, args url.Values) (err error) {
+	_, err = v.client.post(encodeURI(*v.base, path, args))
+	return
+}
+
+func volumeArgs(volumeID uint32) url.Values {
+	return url.Values{"volume": []string{strconv.FormatUint(uint64(volumeID), 10)}}
+}
@@ -0,0 +1,75 @@
+package goseaweedfs
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVolumeAdmin(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+
+		switch {
+		case r.URL.Path == "/status":
+			fmt.Fprint(w, `{"Version":"2.12","Volumes":[{"Id":3,"Size":100,"FileCount":2}]}`)
+		case r.URL.Path == "/admin/volume/delete":
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, `{"error":"volume 9 not found"}`)
+		case r.Method == http.MethodGet:
+			fmt.Fprint(w, "deleted content")
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/admin/"):
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPost:
+			fmt.Fprint(w, `{"name":"a.txt","size":7}`)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+
+	v, err := NewVolumeAdmin(server.URL, server.Client())
+	require.Nil(t, err)
+	defer func() { _ = v.Close() }()
+
+	status, err := v.Status(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, "2.12", status.Version)
+	require.EqualValues(t, 3, status.Volumes[0].ID)
+
+	r, err := v.ReadNeedle("3,01", true, nil)
+	require.Nil(t, err)
+	data, _ := ioutil.ReadAll(r.Body)
+	_ = r.Close()
+	require.Equal(t, "deleted content", string(data))
+
+	result, err := v.WriteNeedle("3,01", "a.txt", strings.NewReader("content"), 7, 1600000000, nil)
+	require.Nil(t, err)
+	require.EqualValues(t, 7, result.Size)
+
+	require.Nil(t, v.DeleteNeedle("3,01"))
+	require.Nil(t, v.AssignVolume(9, "pics", "001", ""))
+	require.Nil(t, v.MountVolume(9))
+	require.Nil(t, v.UnmountVolume(9))
+	err = v.DeleteVolume(9)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "volume 9 not found")
+
+	require.Equal(t, []string{
+		"GET /status",
+		"GET /3,01?readDeleted=true",
+		"POST /3,01?ts=1600000000",
+		"DELETE /3,01",
+		"POST /admin/assign_volume?collection=pics&replication=001&volume=9",
+		"POST /admin/volume/mount?volume=9",
+		"POST /admin/volume/unmount?volume=9",
+		"POST /admin/volume/delete?volume=9",
+	}, requests)
+}